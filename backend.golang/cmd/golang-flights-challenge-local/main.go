@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -17,9 +18,14 @@ import (
 func main() {
 	a := app.New()
 
+	// every request derives from this context, so shutting down cancels whatever is still in-flight
+	baseCtx, cancelBaseCtx := context.WithCancel(context.Background())
+	defer cancelBaseCtx()
+
 	srv := &http.Server{
-		Addr:    fmt.Sprintf(":%s", os.Getenv("PORT")),
-		Handler: a.Handler(),
+		Addr:        fmt.Sprintf(":%s", os.Getenv("PORT")),
+		Handler:     a.Handler(),
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
 	}
 
 	// Start the server in a goroutine
@@ -42,5 +48,8 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Printf("Server shutdown failed: %+v\n", err)
 	}
+
+	// websockets are hijacked connections, which shutdown does not wait for
+	cancelBaseCtx()
 	log.Println("Server stopped")
 }
@@ -0,0 +1,60 @@
+package airports
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strings"
+)
+
+// City represents a metropolitan area served by several airports, e.g NYC for JFK, LGA and EWR
+type City struct {
+	Code     string   `json:"code"`
+	Name     string   `json:"name"`
+	Airports []string `json:"airports"`
+}
+
+//go:embed cities.json
+var citiesData []byte
+
+// cities indexes the embedded reference dataset by city code
+var cities = func() map[string]City {
+	list := []City{}
+	if err := json.Unmarshal(citiesData, &list); err != nil {
+		// the dataset is embedded at build time, so this only happens on a broken build
+		panic(err)
+	}
+
+	index := map[string]City{}
+	for _, city := range list {
+		index[city.Code] = city
+	}
+
+	return index
+}()
+
+// Expand returns the airports a location stands for, which is either a single airport, a city code
+// or a comma separated list of both, e.g "NYC,BOS" expands to JFK, LGA, EWR and BOS
+func Expand(location string) []string {
+	results := []string{}
+	dedupe := map[string]bool{}
+	for _, code := range strings.Split(location, ",") {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code == "" {
+			continue
+		}
+
+		codes := []string{code}
+		if city, ok := cities[code]; ok {
+			codes = city.Airports
+		}
+
+		for _, c := range codes {
+			if !dedupe[c] {
+				dedupe[c] = true
+				results = append(results, c)
+			}
+		}
+	}
+
+	return results
+}
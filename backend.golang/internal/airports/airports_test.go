@@ -0,0 +1,30 @@
+package airports_test
+
+import (
+	"testing"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/airports"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpand(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	run("Single airport is kept as is", func(t *testing.T) {
+		assert.Equal(t, []string{"BKK"}, airports.Expand("BKK"))
+	})
+
+	run("City code expands to its airports", func(t *testing.T) {
+		assert.Equal(t, []string{"JFK", "LGA", "EWR"}, airports.Expand("NYC"))
+		assert.Equal(t, []string{"HND", "NRT"}, airports.Expand("tyo"))
+	})
+
+	run("Comma separated list of airports and cities", func(t *testing.T) {
+		assert.Equal(t, []string{"JFK", "LGA", "EWR", "BOS"}, airports.Expand("NYC, BOS,,JFK"))
+	})
+
+	run("Empty location", func(t *testing.T) {
+		assert.Empty(t, airports.Expand(""))
+	})
+}
@@ -2,19 +2,27 @@ package app
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/cors"
 	infisical "github.com/infisical/go-sdk"
+	"github.com/rubengp99/golang-flights-challenge/internal/breaker"
+	"github.com/rubengp99/golang-flights-challenge/internal/exchange"
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/providers"
 	"github.com/rubengp99/golang-flights-challenge/internal/vendors/amadeus"
 	"github.com/rubengp99/golang-flights-challenge/internal/vendors/flightsky"
 	"github.com/rubengp99/golang-flights-challenge/internal/vendors/googleflights"
 	"github.com/rubengp99/golang-flights-challenge/internal/vendors/redis"
+	"github.com/rubengp99/golang-flights-challenge/internal/workflow"
 	"github.com/rubengp99/golang-flights-challenge/pkg"
 )
 
@@ -23,6 +31,9 @@ type App struct {
 	LogWriter                           io.Writer
 	SecretKey                           string
 	GetBestFlightsHandler               http.HandlerFunc
+	GetMultiCityFlightsHandler          http.HandlerFunc
+	GetCalendarHandler                  http.HandlerFunc
+	GetVendorDiagnosticsHandler         http.HandlerFunc
 	LoginHandler                        http.HandlerFunc
 	SubcribeToFlightOfferUpdatesHandler http.HandlerFunc
 }
@@ -34,25 +45,40 @@ type ProvideInfisicalClientFunc func() infisical.InfisicalClientInterface
 
 // Option is a representation of configurable options for the app
 type Option struct {
-	LogWriter                  io.Writer
-	ProjectUD                  string
-	DisableRedis               bool
-	TimeProvider               func() time.Time
-	ProvideInfisicalClient     ProvideInfisicalClientFunc
-	ProvideAmadeusConfig       amadeus.ConfigProviderFunc
-	ProvideFlightskyConfig     flightsky.ConfigProviderFunc
-	ProvideGoogleflightsConfig googleflights.ConfigProviderFunc
+	LogWriter              io.Writer
+	ProjectUD              string
+	DisableRedis           bool
+	TimeProvider           func() time.Time
+	ProvideInfisicalClient ProvideInfisicalClientFunc
+	// Providers are the vendors searched by source, options are able to add new ones or delete the default ones
+	Providers           map[string]providers.ProvideFunc
+	ProvideRateProvider exchange.ProviderFunc
+	RankingWeights      pkg.RankingWeights
+	VendorPolicy        workflow.VendorPolicy
+	SearchBudget        time.Duration
+	VendorDeadlines     map[string]time.Duration
+	VendorBreaker       breaker.Config
+	VendorQuotas        map[string]workflow.VendorQuota
 }
 
 // New returns an instance of the default app
 func New(options ...Options) App {
 	o := Option{
-		LogWriter:                  os.Stdout,
-		TimeProvider:               time.Now,
-		ProjectUD:                  os.Getenv("PROJECT_ID"),
-		ProvideAmadeusConfig:       amadeus.DefaultConfigFromSecretsManager(),
-		ProvideFlightskyConfig:     flightsky.DefaultConfigFromSecretsManager(),
-		ProvideGoogleflightsConfig: googleflights.DefaultConfigFromSecretsManager(),
+		LogWriter:    os.Stdout,
+		TimeProvider: time.Now,
+		ProjectUD:    os.Getenv("PROJECT_ID"),
+		Providers: map[string]providers.ProvideFunc{
+			pkg.SourceAmadeus:       providers.ProvideAmadeus(amadeus.DefaultConfigFromSecretsManager()),
+			pkg.SourceFlightsky:     providers.ProvideFlightsky(flightsky.DefaultConfigFromSecretsManager()),
+			pkg.SourceGoogleflights: providers.ProvideGoogleflights(googleflights.DefaultConfigFromSecretsManager()),
+		},
+		ProvideRateProvider: exchange.DefaultFileProvider(),
+		RankingWeights:      defaultRankingWeights(),
+		VendorPolicy:        defaultVendorPolicy(),
+		SearchBudget:        defaultSearchBudget(),
+		VendorDeadlines:     defaultVendorDeadlines(),
+		VendorBreaker:       defaultVendorBreaker(),
+		VendorQuotas:        defaultVendorQuotas(),
 		ProvideInfisicalClient: func() infisical.InfisicalClientInterface {
 			client := infisical.NewInfisicalClient(context.Background(), infisical.Config{})
 			client.Auth().SetAccessToken(os.Getenv("INFISICAL_TOKEN"))
@@ -65,19 +91,24 @@ func New(options ...Options) App {
 	}
 
 	var (
-		errors              = make(chan error)
-		wgdone              = make(chan bool)
-		wg                  sync.WaitGroup
-		secretKey           = ""
-		clientID            = ""
-		clientSecret        = ""
-		amadeusClient       amadeus.Service
-		flightskyClient     flightsky.Service
-		googleflightsClient googleflights.Service
+		errors       = make(chan error)
+		wgdone       = make(chan bool)
+		wg           sync.WaitGroup
+		secretKey    = ""
+		clientID     = ""
+		clientSecret = ""
+		mu           sync.Mutex
+		registered   = []providers.FlightProvider{}
 	)
 
 	infisicalClient := o.ProvideInfisicalClient()
 	redisClient := redis.NewRedisService(o.DisableRedis)
+	rates, err := o.ProvideRateProvider()
+	if err != nil {
+		// we cannot quote prices without exchange rates, so we panic
+		panic(err)
+	}
+
 	// retrieve all secrets from infisical
 	secrets := []func(channel chan error){
 		func(channel chan error) {
@@ -119,18 +150,16 @@ func New(options ...Options) App {
 			}
 			wg.Done()
 		},
-		func(channel chan error) {
-			googleflightsClient = googleflights.NewService(o.ProvideGoogleflightsConfig, infisicalClient, o.ProjectUD)
-			wg.Done()
-		},
-		func(channel chan error) {
-			amadeusClient = amadeus.NewService(o.ProvideAmadeusConfig, infisicalClient, o.ProjectUD)
-			wg.Done()
-		},
-		func(channel chan error) {
-			flightskyClient = flightsky.NewService(o.ProvideFlightskyConfig, infisicalClient, o.ProjectUD)
+	}
+
+	for _, provide := range o.Providers {
+		secrets = append(secrets, func(channel chan error) {
+			provider := provide(infisicalClient, o.ProjectUD)
+			mu.Lock()
+			registered = append(registered, provider)
+			mu.Unlock()
 			wg.Done()
-		},
+		})
 	}
 
 	wg.Add(len(secrets))
@@ -152,6 +181,16 @@ func New(options ...Options) App {
 		panic(err)
 	}
 
+	registry := providers.NewRegistry(registered...)
+	config := workflow.Config{
+		RankingWeights:  o.RankingWeights,
+		VendorPolicy:    o.VendorPolicy,
+		SearchBudget:    o.SearchBudget,
+		VendorDeadlines: o.VendorDeadlines,
+		Breakers:        breaker.New(o.VendorBreaker, o.TimeProvider),
+		VendorQuotas:    o.VendorQuotas,
+	}
+
 	// default credentials for application, simple for demo purposes
 	appCredentials := pkg.CrendetialsRequest{
 		ClientID:     clientID,
@@ -162,9 +201,123 @@ func New(options ...Options) App {
 		SecretKey:                           secretKey,
 		LogWriter:                           o.LogWriter,
 		LoginHandler:                        LoginHandler(appCredentials, secretKey),
-		GetBestFlightsHandler:               RetrieveBestFlightsHandler(redisClient, googleflightsClient, amadeusClient, flightskyClient),
-		SubcribeToFlightOfferUpdatesHandler: SubcribeToFlightOfferUpdatesHandler(secretKey, redisClient, googleflightsClient, amadeusClient, flightskyClient),
+		GetBestFlightsHandler:               RetrieveBestFlightsHandler(redisClient, rates, config, registry),
+		GetMultiCityFlightsHandler:          RetrieveMultiCityFlightsHandler(redisClient, rates, config, registry),
+		GetCalendarHandler:                  RetrieveCalendarHandler(redisClient, rates, registry),
+		GetVendorDiagnosticsHandler:         RetrieveVendorDiagnosticsHandler(redisClient, config, registry),
+		SubcribeToFlightOfferUpdatesHandler: SubcribeToFlightOfferUpdatesHandler(secretKey, redisClient, rates, config, registry),
+	}
+}
+
+// defaultRankingWeights allows deployments to tune how best flight offers are ranked through RANKING_WEIGHTS,
+// e.g {"price":0.6,"duration":0.4}, criteria not included are not taken into account
+func defaultRankingWeights() pkg.RankingWeights {
+	value := os.Getenv("RANKING_WEIGHTS")
+	if value == "" {
+		return mapping.DefaultRankingWeights
 	}
+
+	var weights pkg.RankingWeights
+	if err := json.Unmarshal([]byte(value), &weights); err != nil {
+		// we cannot proceed with a broken deployment config, so we panic
+		panic(err)
+	}
+
+	return weights
+}
+
+// defaultVendorPolicy allows deployments to decide how many vendors should succeed on a search through VENDOR_POLICY,
+// e.g FAIL_ALL, BEST_EFFORT or REQUIRE_2
+func defaultVendorPolicy() workflow.VendorPolicy {
+	value := os.Getenv("VENDOR_POLICY")
+	if value == "" {
+		return workflow.DefaultVendorPolicy
+	}
+
+	policy, err := workflow.ParseVendorPolicy(value)
+	if err != nil {
+		// we cannot proceed with a broken deployment config, so we panic
+		panic(err)
+	}
+
+	return policy
+}
+
+// defaultSearchBudget allows deployments to decide how long searches wait for vendors through SEARCH_BUDGET, e.g 8s
+func defaultSearchBudget() time.Duration {
+	value := os.Getenv("SEARCH_BUDGET")
+	if value == "" {
+		return workflow.DefaultSearchBudget
+	}
+
+	budget, err := time.ParseDuration(value)
+	if err != nil {
+		// we cannot proceed with a broken deployment config, so we panic
+		panic(err)
+	}
+
+	return budget
+}
+
+// defaultVendorDeadlines allows deployments to wait less for some vendors than for the whole search through
+// VENDOR_DEADLINES, e.g amadeus=6s,flightsky=4s
+func defaultVendorDeadlines() map[string]time.Duration {
+	value := os.Getenv("VENDOR_DEADLINES")
+	if value == "" {
+		return map[string]time.Duration{}
+	}
+
+	deadlines, err := workflow.ParseVendorDeadlines(value)
+	if err != nil {
+		// we cannot proceed with a broken deployment config, so we panic
+		panic(err)
+	}
+
+	return deadlines
+}
+
+// defaultVendorBreaker allows deployments to tune when vendors are skipped through BREAKER_THRESHOLD,
+// the consecutive failures opening the circuit, and BREAKER_COOLDOWN, e.g 1m
+func defaultVendorBreaker() breaker.Config {
+	config := breaker.DefaultConfig
+	if value := os.Getenv("BREAKER_THRESHOLD"); value != "" {
+		threshold, err := strconv.Atoi(value)
+		if err != nil || threshold < 1 {
+			// we cannot proceed with a broken deployment config, so we panic
+			panic(fmt.Sprintf("invalid BREAKER_THRESHOLD %s", value))
+		}
+
+		config.FailureThreshold = threshold
+	}
+
+	if value := os.Getenv("BREAKER_COOLDOWN"); value != "" {
+		coolDown, err := time.ParseDuration(value)
+		if err != nil {
+			// we cannot proceed with a broken deployment config, so we panic
+			panic(err)
+		}
+
+		config.CoolDown = coolDown
+	}
+
+	return config
+}
+
+// defaultVendorQuotas allows deployments to limit the calls made to vendors billed per call through VENDOR_QUOTAS,
+// e.g flightsky=5/s:1000/day:20000/month,googleflights=100/day
+func defaultVendorQuotas() map[string]workflow.VendorQuota {
+	value := os.Getenv("VENDOR_QUOTAS")
+	if value == "" {
+		return map[string]workflow.VendorQuota{}
+	}
+
+	quotas, err := workflow.ParseVendorQuotas(value)
+	if err != nil {
+		// we cannot proceed with a broken deployment config, so we panic
+		panic(err)
+	}
+
+	return quotas
 }
 
 // Handler returns the main http handler for the application
@@ -192,6 +345,9 @@ func (a *App) Handler() http.HandlerFunc {
 	router.Group(func(r chi.Router) {
 		r.Use(newMiddleware(a.LogWriter, a.SecretKey, true).Wrap)
 		r.Get("/flights/search", a.GetBestFlightsHandler)
+		r.Post("/flights/search/multi-city", a.GetMultiCityFlightsHandler)
+		r.Get("/flights/calendar", a.GetCalendarHandler)
+		r.Get("/diagnostics/vendors", a.GetVendorDiagnosticsHandler)
 	})
 
 	// no auth required routes
@@ -203,6 +359,9 @@ func (a *App) Handler() http.HandlerFunc {
 	})
 
 	router.Options("/flights/search", defaultOptionsHandler)
+	router.Options("/flights/search/multi-city", defaultOptionsHandler)
+	router.Options("/flights/calendar", defaultOptionsHandler)
+	router.Options("/diagnostics/vendors", defaultOptionsHandler)
 	router.Options("/login", defaultOptionsHandler)
 	router.Options("/subscribe", defaultOptionsHandler)
 
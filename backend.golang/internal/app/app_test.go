@@ -10,12 +10,18 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	infisical "github.com/infisical/go-sdk"
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/providers"
 	"github.com/rubengp99/golang-flights-challenge/internal/vendors"
 	"github.com/rubengp99/golang-flights-challenge/internal/vendors/amadeus"
 	"github.com/rubengp99/golang-flights-challenge/internal/vendors/flightsky"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/redis"
 	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
 	"github.com/rubengp99/golang-flights-challenge/pkg"
 	"github.com/stretchr/testify/assert"
@@ -39,11 +45,11 @@ func mockInfisicalClient(baseURL string) Options {
 
 func mockGoogleflightsConfig() Options {
 	return func(o *Option) {
-		o.ProvideGoogleflightsConfig = func(infclient infisical.InfisicalClientInterface, projectID string) vendors.Config {
+		o.Providers[pkg.SourceGoogleflights] = providers.ProvideGoogleflights(func(infclient infisical.InfisicalClientInterface, projectID string) vendors.Config {
 			return vendors.Config{
 				Disabled: true,
 			}
-		}
+		})
 	}
 }
 
@@ -88,6 +94,21 @@ func mockAmadeusServer(t *testing.T) *httptest.Server {
 				assert.Equal(t, "Bearer TestAccessToken", r.Header.Get("Authorization"))
 			})
 
+			w.WriteHeader(http.StatusOK)
+			w.Write(data)
+		case "/v2/shopping/flight-offers?":
+			var response amadeus.APIResponse
+			reader := testhelpers.FileToStruct(t, filepath.Join("testdata", "amadeus-offers.json"), &response)
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			run("Method as expected", func(t *testing.T) {
+				assert.Equal(t, http.MethodPost, r.Method)
+			})
+
 			w.WriteHeader(http.StatusOK)
 			w.Write(data)
 		case "/v1/security/oauth2/token?":
@@ -374,6 +395,77 @@ func TestGetBestFlightOffersResponse(t *testing.T) {
 	})
 }
 
+func TestGetMultiCityFlightOffersResponse(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	amadeusServer := mockAmadeusServer(t)
+	defer amadeusServer.Close()
+
+	flightskyServer := mockFlightskyServer(t)
+	defer flightskyServer.Close()
+
+	testInfisical := mockInfisicalServer(t, amadeusServer.URL, flightskyServer.URL)
+	defer testInfisical.Close()
+
+	a := New(
+		mockInfisicalClient(testInfisical.URL),
+		mockGoogleflightsConfig(),
+		func(o *Option) {
+			o.DisableRedis = true
+		},
+	)
+
+	testServer := httptest.NewServer(a.Handler())
+	defer testServer.Close()
+
+	// generate a new fresh token, as these have 1 day expiration
+	var reqDTO pkg.CrendetialsRequest
+	payload := testhelpers.FileToStruct(t, filepath.Join("testdata", "login-request.json"), &reqDTO)
+
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("%v/login", testServer.URL), payload)
+	res, err := http.DefaultClient.Do(req)
+
+	run("No http error", func(t *testing.T) {
+		assert.NoError(t, err)
+	})
+
+	data, err := io.ReadAll(res.Body)
+	run("No read error", func(t *testing.T) {
+		assert.NoError(t, err)
+	})
+
+	var resDTO pkg.CredentialsResponse
+	run("No unmarshal error", func(t *testing.T) {
+		assert.NoError(t, json.Unmarshal(data, &resDTO))
+	})
+
+	// use the token and now access to multi-city flights API, flightsky should be skipped
+	var searchDTO pkg.QueryParams
+	payload = testhelpers.FileToStruct(t, filepath.Join("testdata", "multi-city-request.json"), &searchDTO)
+	req, _ = http.NewRequest(http.MethodPost, fmt.Sprintf("%v/flights/search/multi-city", testServer.URL), payload)
+	req.Header.Add("Authorization", "Bearer "+resDTO.AccessToken)
+
+	res, err = http.DefaultClient.Do(req)
+	run("No error", func(t *testing.T) {
+		assert.NoError(t, err)
+	})
+
+	run("HTTP Status response is as expected", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	data, err = io.ReadAll(res.Body)
+	run("No read error", func(t *testing.T) {
+		assert.NoError(t, err)
+	})
+
+	var flightsDTO pkg.GetBestFlightOffersResponse
+	run("Response body is as expected", func(t *testing.T) {
+		assert.NoError(t, json.Unmarshal(data, &flightsDTO))
+		assert.Len(t, flightsDTO.Cheapest, 3)
+	})
+}
+
 func TestGetBestFlightOffersResponseUnAuthorized(t *testing.T) {
 	run := testhelpers.Run(t)
 
@@ -416,3 +508,319 @@ func TestGetBestFlightOffersResponseUnAuthorized(t *testing.T) {
 	})
 
 }
+
+func TestGetVendorDiagnosticsResponse(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	amadeusServer := mockAmadeusServer(t)
+	defer amadeusServer.Close()
+
+	flightskyServer := mockFlightskyServer(t)
+	defer flightskyServer.Close()
+
+	testInfisical := mockInfisicalServer(t, amadeusServer.URL, flightskyServer.URL)
+	defer testInfisical.Close()
+
+	a := New(
+		mockInfisicalClient(testInfisical.URL),
+		mockGoogleflightsConfig(),
+		func(o *Option) {
+			o.DisableRedis = true
+		},
+	)
+
+	testServer := httptest.NewServer(a.Handler())
+	defer testServer.Close()
+
+	// generate a new fresh token, as these have 1 day expiration
+	var reqDTO pkg.CrendetialsRequest
+	payload := testhelpers.FileToStruct(t, filepath.Join("testdata", "login-request.json"), &reqDTO)
+
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("%v/login", testServer.URL), payload)
+	res, err := http.DefaultClient.Do(req)
+	run("No login error", func(t *testing.T) {
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	var resDTO pkg.CredentialsResponse
+	data, _ := io.ReadAll(res.Body)
+	run("Token is present", func(t *testing.T) {
+		assert.NoError(t, json.Unmarshal(data, &resDTO))
+		assert.NotEmpty(t, resDTO.AccessToken)
+	})
+
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("%v/diagnostics/vendors", testServer.URL), nil)
+	req.Header.Add("Authorization", "Bearer "+resDTO.AccessToken)
+
+	res, err = http.DefaultClient.Do(req)
+	run("No error", func(t *testing.T) {
+		assert.NoError(t, err)
+	})
+
+	run("HTTP Status response is as expected", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	var diagnostics pkg.GetVendorDiagnosticsResponse
+	data, _ = io.ReadAll(res.Body)
+	run("Every vendor is reported closed", func(t *testing.T) {
+		assert.NoError(t, json.Unmarshal(data, &diagnostics))
+		assert.Len(t, diagnostics.Vendors, 3)
+		for _, vendor := range diagnostics.Vendors {
+			assert.Equal(t, pkg.BreakerClosed, vendor.Breaker.State, vendor.Source)
+		}
+	})
+}
+
+// stubDatesProvider quotes a single calendar fare, standing for any vendor pricing flight dates
+type stubDatesProvider struct {
+	fare pkg.CalendarDay
+}
+
+func (s stubDatesProvider) Source() string {
+	return pkg.SourceAmadeus
+}
+
+func (s stubDatesProvider) Capabilities() providers.Capabilities {
+	return providers.Capabilities{}
+}
+
+func (s stubDatesProvider) RetrieveFlightOffers(ctx context.Context, params pkg.QueryParams) ([]pkg.FlightOffer, error) {
+	return nil, nil
+}
+
+func (s stubDatesProvider) RetrieveFlightDates(ctx context.Context, origin, destination string, from, to time.Time) ([]pkg.CalendarDay, error) {
+	return []pkg.CalendarDay{s.fare}, nil
+}
+
+// newAccessToken logs into the app, returning a fresh token
+func newAccessToken(t *testing.T, serverURL string) string {
+	var reqDTO pkg.CrendetialsRequest
+	payload := testhelpers.FileToStruct(t, filepath.Join("testdata", "login-request.json"), &reqDTO)
+
+	res, err := http.Post(fmt.Sprintf("%v/login", serverURL), "application/json", payload)
+	if err != nil || res.StatusCode != http.StatusOK {
+		t.Fatalf("unable to login, error: %v", err)
+	}
+
+	var resDTO pkg.CredentialsResponse
+	if err := json.NewDecoder(res.Body).Decode(&resDTO); err != nil {
+		t.Fatal(err)
+	}
+
+	return resDTO.AccessToken
+}
+
+func TestGetCalendarResponse(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	redisServer := miniredis.RunT(t)
+	t.Setenv("REDIS_URL", redisServer.Addr())
+
+	testInfisical := mockInfisicalServer(t, "", "")
+	defer testInfisical.Close()
+
+	// next month is fully in the future, so vendors are asked for every day of it
+	now := time.Now().UTC()
+	firstDay := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+	month := firstDay.Format("2006-01")
+	quotedAt := now.Add(-time.Hour)
+
+	a := New(
+		mockInfisicalClient(testInfisical.URL),
+		func(o *Option) {
+			o.Providers = map[string]providers.ProvideFunc{
+				pkg.SourceAmadeus: func(client infisical.InfisicalClientInterface, projectID string) providers.FlightProvider {
+					return stubDatesProvider{fare: mapping.NewFareCalendarDay(pkg.SourceAmadeus, firstDay.AddDate(0, 0, 9), now, pkg.Amount{Value: 250, Currency: "USD"})}
+				},
+			}
+		},
+	)
+
+	// a previous search on the route recorded its lowest fares
+	recorded := []pkg.CalendarDay{
+		mapping.NewFareCalendarDay(pkg.SourceFlightsky, firstDay.AddDate(0, 0, 9), quotedAt, pkg.Amount{Value: 275, Currency: "USD"}),
+		mapping.NewFareCalendarDay(pkg.SourceFlightsky, firstDay.AddDate(0, 0, 19), quotedAt, pkg.Amount{Value: 300, Currency: "USD"}),
+	}
+	for _, fare := range recorded {
+		if err := redis.NewRedisService(false).RecordCalendarFare(context.Background(), "SYD", "BKK", fare); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testServer := httptest.NewServer(a.Handler())
+	defer testServer.Close()
+
+	token := newAccessToken(t, testServer.URL)
+	getCalendar := func(month string) *http.Response {
+		params := url.Values{}
+		params.Add("origin", "SYD")
+		params.Add("destination", "BKK")
+		params.Add("month", month)
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("%v/flights/calendar?%s", testServer.URL, params.Encode()), nil)
+		req.Header.Add("Authorization", "Bearer "+token)
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return res
+	}
+
+	res := getCalendar(month)
+	run("HTTP Status response is as expected", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	var calendar pkg.GetCalendarResponse
+	run("No unmarshal error", func(t *testing.T) {
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&calendar))
+	})
+
+	run("Every day of the month is present", func(t *testing.T) {
+		assert.Equal(t, month, calendar.Month)
+		assert.Len(t, calendar.Days, firstDay.AddDate(0, 1, -1).Day())
+		assert.Nil(t, calendar.Days[0].Price)
+	})
+
+	run("Vendor and recorded fares are combined, lowest first", func(t *testing.T) {
+		assert.Equal(t, 250.0, calendar.Days[9].Price.Value)
+		assert.Equal(t, pkg.SourceAmadeus, calendar.Days[9].Source)
+		assert.Equal(t, 300.0, calendar.Days[19].Price.Value)
+		assert.Equal(t, pkg.SourceFlightsky, calendar.Days[19].Source)
+		assert.Equal(t, pkg.FreshnessCached, calendar.Days[19].Freshness)
+	})
+
+	run("Invalid months are rejected", func(t *testing.T) {
+		for _, month := range []string{"2025-13", "2025-5", "May 2025"} {
+			res := getCalendar(month)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, month)
+		}
+	})
+}
+
+func TestGetBestFlightOffersUnsupportedCurrency(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	vendorCalls := atomic.Int32{}
+	vendorServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vendorCalls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer vendorServer.Close()
+
+	testInfisical := mockInfisicalServer(t, vendorServer.URL, vendorServer.URL)
+	defer testInfisical.Close()
+
+	a := New(
+		mockInfisicalClient(testInfisical.URL),
+		mockGoogleflightsConfig(),
+		func(o *Option) {
+			o.DisableRedis = true
+		},
+	)
+
+	testServer := httptest.NewServer(a.Handler())
+	defer testServer.Close()
+
+	token := newAccessToken(t, testServer.URL)
+	search := func(currency string) *http.Response {
+		params := url.Values{}
+		params.Add("date", "2025-05-09")
+		params.Add("origin", "SYD")
+		params.Add("adults", "1")
+		params.Add("destination", "BKK")
+		params.Add("currency", currency)
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("%v/flights/search?%s", testServer.URL, params.Encode()), nil)
+		req.Header.Add("Authorization", "Bearer "+token)
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return res
+	}
+
+	run("Currencies without exchange rates are rejected", func(t *testing.T) {
+		res := search("XYZ")
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		assert.Contains(t, fmt.Sprint(body), "CURRENCY XYZ is not supported")
+	})
+
+	run("Malformed currencies are rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, search("usd").StatusCode)
+	})
+
+	run("Vendors are not searched", func(t *testing.T) {
+		assert.Zero(t, vendorCalls.Load())
+	})
+}
+
+func TestGetBestFlightOffersInvalidCursor(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	vendorCalls := atomic.Int32{}
+	vendorServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vendorCalls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer vendorServer.Close()
+
+	testInfisical := mockInfisicalServer(t, vendorServer.URL, vendorServer.URL)
+	defer testInfisical.Close()
+
+	a := New(
+		mockInfisicalClient(testInfisical.URL),
+		mockGoogleflightsConfig(),
+		func(o *Option) {
+			o.DisableRedis = true
+		},
+	)
+
+	testServer := httptest.NewServer(a.Handler())
+	defer testServer.Close()
+
+	token := newAccessToken(t, testServer.URL)
+	search := func(cursor string) *http.Response {
+		params := url.Values{}
+		params.Add("date", "2025-05-09")
+		params.Add("origin", "SYD")
+		params.Add("adults", "1")
+		params.Add("destination", "BKK")
+		params.Add("limit", "2")
+		params.Add("cursor", cursor)
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("%v/flights/search?%s", testServer.URL, params.Encode()), nil)
+		req.Header.Add("Authorization", "Bearer "+token)
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return res
+	}
+
+	run("Malformed cursors are rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, search("not a cursor").StatusCode)
+	})
+
+	run("Cursors of expired snapshots are rejected", func(t *testing.T) {
+		// snapshots are never found while redis is disabled
+		res := search("ZXhwaXJlZDoy")
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		assert.Contains(t, fmt.Sprint(body), "invalid or expired cursor")
+	})
+
+	run("Vendors are not searched", func(t *testing.T) {
+		assert.Zero(t, vendorCalls.Load())
+	})
+}
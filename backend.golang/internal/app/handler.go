@@ -1,19 +1,21 @@
 package app
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 	"github.com/gorilla/schema"
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
-	"github.com/rubengp99/golang-flights-challenge/internal/vendors/amadeus"
-	"github.com/rubengp99/golang-flights-challenge/internal/vendors/flightsky"
-	"github.com/rubengp99/golang-flights-challenge/internal/vendors/googleflights"
+	"github.com/rubengp99/golang-flights-challenge/internal/exchange"
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/providers"
 	"github.com/rubengp99/golang-flights-challenge/internal/vendors/redis"
 	"github.com/rubengp99/golang-flights-challenge/internal/workflow"
 	"github.com/rubengp99/golang-flights-challenge/pkg"
@@ -39,6 +41,17 @@ func getQueryParams(value interface{}, r *http.Request) error {
 		return reflect.Value{} // this is the same as the private const invalidType
 	})
 
+	// lists are provided as comma separated values, e.g JFK,LGA
+	decoder.RegisterConverter([]string{}, func(value string) reflect.Value {
+		values := []string{}
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		return reflect.ValueOf(values)
+	})
+
 	if err := decoder.Decode(value, r.URL.Query()); err != nil {
 		return errors.Wrap(err, "handler - failed to decode query params")
 	}
@@ -65,9 +78,9 @@ func createToken(secretKey, clientID string) (string, int64, error) {
 
 // RetrieveBestFlightsHandler handles best flights lookup
 func RetrieveBestFlightsHandler(redisClient redis.Service,
-	googleflightService googleflights.Service,
-	amadeusService amadeus.Service,
-	flightskyService flightsky.Service) http.HandlerFunc {
+	rates exchange.RateProvider,
+	config workflow.Config,
+	registry providers.Registry) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var params pkg.QueryParams
 		if err := getQueryParams(&params, r); err != nil {
@@ -75,13 +88,87 @@ func RetrieveBestFlightsHandler(redisClient redis.Service,
 			return
 		}
 
-		if err := validateBestFlightsParamsRequest(params); err != nil {
+		if err := validateBestFlightsParamsRequest(rates, params); err != nil {
+			serveResponse(newError(err.Error()), http.StatusBadRequest, w)
+			return
+		}
+
+		wf := workflow.RetrieveBestFlights(redisClient, rates, config, registry)
+		res, err := wf(r.Context(), params)
+		if errors.Is(err, workflow.ErrInvalidCursor) {
 			serveResponse(newError(err.Error()), http.StatusBadRequest, w)
 			return
 		}
 
-		wf := workflow.RetrieveBestFlights(redisClient, googleflightService, amadeusService, flightskyService)
-		res, err := wf(params)
+		if err != nil {
+			serveResponse(newError(err.Error()), http.StatusInternalServerError, w)
+			return
+		}
+		serveResponse(res, http.StatusOK, w)
+	})
+}
+
+// RetrieveMultiCityFlightsHandler handles best flights lookup for multi-city itineraries
+func RetrieveMultiCityFlightsHandler(redisClient redis.Service,
+	rates exchange.RateProvider,
+	config workflow.Config,
+	registry providers.Registry) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params pkg.QueryParams
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			serveResponse(newError(err.Error()), http.StatusBadRequest, w)
+			return
+		}
+
+		if err := validateMultiCityParamsRequest(rates, params); err != nil {
+			serveResponse(newError(err.Error()), http.StatusBadRequest, w)
+			return
+		}
+
+		wf := workflow.RetrieveBestFlights(redisClient, rates, config, registry)
+		res, err := wf(r.Context(), params)
+		if errors.Is(err, workflow.ErrInvalidCursor) {
+			serveResponse(newError(err.Error()), http.StatusBadRequest, w)
+			return
+		}
+
+		if err != nil {
+			serveResponse(newError(err.Error()), http.StatusInternalServerError, w)
+			return
+		}
+		serveResponse(res, http.StatusOK, w)
+	})
+}
+
+// RetrieveCalendarHandler handles the price calendar lookup for a month
+func RetrieveCalendarHandler(redisClient redis.Service, rates exchange.RateProvider, registry providers.Registry) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params pkg.CalendarQueryParams
+		if err := getQueryParams(&params, r); err != nil {
+			serveResponse(newError(err.Error()), http.StatusInternalServerError, w)
+			return
+		}
+
+		if err := validateCalendarParamsRequest(rates, params); err != nil {
+			serveResponse(newError(err.Error()), http.StatusBadRequest, w)
+			return
+		}
+
+		wf := workflow.RetrieveCalendar(redisClient, rates, registry)
+		res, err := wf(r.Context(), params)
+		if err != nil {
+			serveResponse(newError(err.Error()), http.StatusInternalServerError, w)
+			return
+		}
+		serveResponse(res, http.StatusOK, w)
+	})
+}
+
+// RetrieveVendorDiagnosticsHandler reports the health of every vendor searched
+func RetrieveVendorDiagnosticsHandler(redisClient redis.Service, config workflow.Config, registry providers.Registry) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wf := workflow.RetrieveVendorDiagnostics(redisClient, config, registry)
+		res, err := wf(r.Context())
 		if err != nil {
 			serveResponse(newError(err.Error()), http.StatusInternalServerError, w)
 			return
@@ -127,9 +214,9 @@ func LoginHandler(appCreds pkg.CrendetialsRequest, secretKey string) http.Handle
 // SubcribeToFlightOfferUpdatesHandler handles periodic updates to a flight search criteria using websockets
 func SubcribeToFlightOfferUpdatesHandler(secret string,
 	redisClient redis.Service,
-	googleflightService googleflights.Service,
-	amadeusService amadeus.Service,
-	flightskyService flightsky.Service) http.HandlerFunc {
+	rates exchange.RateProvider,
+	config workflow.Config,
+	registry providers.Registry) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// we need mandatory params in order to subscribe to updates for an specific request
 		var params pkg.QueryParams
@@ -138,7 +225,7 @@ func SubcribeToFlightOfferUpdatesHandler(secret string,
 			return
 		}
 
-		if err := validateBestFlightsParamsRequest(params); err != nil {
+		if err := validateBestFlightsParamsRequest(rates, params); err != nil {
 			serveResponse(newError(err.Error()), http.StatusBadRequest, w)
 			return
 		}
@@ -161,19 +248,41 @@ func SubcribeToFlightOfferUpdatesHandler(secret string,
 		}
 		defer conn.Close()
 
+		// the connection is only read to notice the client going away, so in-flight searches are cancelled
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		go func() {
+			defer cancel()
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		// every update is a new search, so it is paged in memory instead of snapshotting it for a cursor
+		limit := params.Limit
+		params.Limit, params.Cursor = 0, ""
+
 		ticker := time.NewTicker(30 * time.Second)
 		defer ticker.Stop()
 
 		for {
 			select {
+			case <-ctx.Done():
+				return
 			case <-ticker.C:
-				wf := workflow.RetrieveBestFlights(redisClient, googleflightService, amadeusService, flightskyService)
-				res, err := wf(params)
+				wf := workflow.RetrieveBestFlights(redisClient, rates, config, registry)
+				res, err := wf(ctx, params)
 				if err != nil {
 					serveResponse(newError(err.Error()), http.StatusInternalServerError, w)
 					return
 				}
 
+				if limit > 0 {
+					res = mapping.NewBestFlightsOffersPage(res, 0, limit)
+				}
+
 				if err := conn.WriteJSON(res); err != nil {
 					log.Println("Write error:", err)
 					return
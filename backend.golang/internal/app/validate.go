@@ -2,11 +2,16 @@ package app
 
 import (
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/rubengp99/golang-flights-challenge/internal/airports"
+	"github.com/rubengp99/golang-flights-challenge/internal/exchange"
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
 	"github.com/rubengp99/golang-flights-challenge/pkg"
 )
 
-func validateBestFlightsParamsRequest(req pkg.QueryParams) error {
+func validateBestFlightsParamsRequest(rates exchange.RateProvider, req pkg.QueryParams) error {
 	if req.Origin == "" {
 		return fmt.Errorf("ORIGIN should not be empty")
 	}
@@ -15,14 +20,364 @@ func validateBestFlightsParamsRequest(req pkg.QueryParams) error {
 		return fmt.Errorf("DESTINATION should not be empty")
 	}
 
-	if req.Adults == "" {
-		return fmt.Errorf("ADULTS should not be empty")
+	if err := validateAirports(req); err != nil {
+		return err
+	}
+
+	if err := validatePassengers(req); err != nil {
+		return err
+	}
+
+	if err := validateCabin(req); err != nil {
+		return err
+	}
+
+	if err := validateMaxStops(req); err != nil {
+		return err
+	}
+
+	if err := validateConnections(req); err != nil {
+		return err
+	}
+
+	if err := validateCurrency(rates, req.Currency); err != nil {
+		return err
+	}
+
+	if err := validateRanking(req); err != nil {
+		return err
+	}
+
+	if err := validateFilters(req); err != nil {
+		return err
+	}
+
+	if err := validateSort(req); err != nil {
+		return err
+	}
+
+	if err := validatePareto(req); err != nil {
+		return err
+	}
+
+	if err := validatePagination(req); err != nil {
+		return err
 	}
 
 	if req.Date.Unix() <= 0 {
 		return fmt.Errorf("DATE should not be empty")
 	}
 
+	if req.IsRoundTrip() && req.ReturnDate.Before(req.Date) {
+		return fmt.Errorf("RETURN_DATE should not be before DATE")
+	}
+
+	// every day within the window is a search on its own, so we keep it small
+	if req.FlexDays < 0 || req.FlexDays > 3 {
+		return fmt.Errorf("FLEX_DAYS should be between 0 and 3")
+	}
+
+	// every airport pair is searched on every day of the window, so both are limited together
+	if days := 2*req.FlexDays + 1; airportPairs(req)*days > maxSearches {
+		return fmt.Errorf("ORIGIN and DESTINATION airport pairs should not exceed %d searches across FLEX_DAYS", maxSearches)
+	}
+
+	return nil
+}
+
+func validateCalendarParamsRequest(rates exchange.RateProvider, req pkg.CalendarQueryParams) error {
+	if req.Origin == "" {
+		return fmt.Errorf("ORIGIN should not be empty")
+	}
+
+	if req.Destination == "" {
+		return fmt.Errorf("DESTINATION should not be empty")
+	}
+
+	if req.Month == "" {
+		return fmt.Errorf("MONTH should not be empty")
+	}
+
+	if _, err := req.FirstDay(); err != nil {
+		return fmt.Errorf("MONTH should be formatted as YYYY-MM")
+	}
+
+	if err := validateCurrency(rates, req.Currency); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func validateMultiCityParamsRequest(rates exchange.RateProvider, req pkg.QueryParams) error {
+	// amadeus allows up to 6 origin and destination pairs per search
+	if len(req.Legs) < 2 || len(req.Legs) > 6 {
+		return fmt.Errorf("LEGS should contain between 2 and 6 legs")
+	}
+
+	for i, leg := range req.Legs {
+		if leg.Origin == "" {
+			return fmt.Errorf("LEGS[%d].ORIGIN should not be empty", i)
+		}
+
+		if leg.Destination == "" {
+			return fmt.Errorf("LEGS[%d].DESTINATION should not be empty", i)
+		}
+
+		if leg.Date.Unix() <= 0 {
+			return fmt.Errorf("LEGS[%d].DATE should not be empty", i)
+		}
+
+		if i > 0 && leg.Date.Before(req.Legs[i-1].Date) {
+			return fmt.Errorf("LEGS[%d].DATE should not be before the previous leg date", i)
+		}
+	}
+
+	if err := validatePassengers(req); err != nil {
+		return err
+	}
+
+	if err := validateCabin(req); err != nil {
+		return err
+	}
+
+	if err := validateMaxStops(req); err != nil {
+		return err
+	}
+
+	if err := validateConnections(req); err != nil {
+		return err
+	}
+
+	if err := validateCurrency(rates, req.Currency); err != nil {
+		return err
+	}
+
+	if err := validateRanking(req); err != nil {
+		return err
+	}
+
+	if err := validateFilters(req); err != nil {
+		return err
+	}
+
+	if err := validateSort(req); err != nil {
+		return err
+	}
+
+	if err := validatePareto(req); err != nil {
+		return err
+	}
+
+	if err := validatePagination(req); err != nil {
+		return err
+	}
+
+	if req.FlexDays != 0 {
+		return fmt.Errorf("FLEX_DAYS is not available for multi-city searches")
+	}
+
+	return nil
+}
+
+// maxSearches limits the searches a single request sends to each vendor, as most vendors search every
+// airport pair on every day on its own
+const maxSearches = 16
+
+func validateAirports(req pkg.QueryParams) error {
+	pairs := airportPairs(req)
+	if pairs == 0 {
+		return fmt.Errorf("ORIGIN and DESTINATION should not be the same")
+	}
+
+	if pairs > maxSearches {
+		return fmt.Errorf("ORIGIN and DESTINATION should not expand to more than %d airport pairs", maxSearches)
+	}
+
+	return nil
+}
+
+// airportPairs counts the origin and destination airport pairs a search expands to
+func airportPairs(req pkg.QueryParams) int {
+	pairs := 0
+	for _, origin := range airports.Expand(req.Origin) {
+		for _, destination := range airports.Expand(req.Destination) {
+			if origin != destination {
+				pairs++
+			}
+		}
+	}
+
+	return pairs
+}
+
+// validateCurrency checks the currency is an ISO 4217 code we have exchange rates for,
+// so unsupported currencies are rejected before searching any vendor
+func validateCurrency(rates exchange.RateProvider, currency string) error {
+	if currency == "" {
+		return nil
+	}
+
+	if len(currency) != 3 {
+		return fmt.Errorf("CURRENCY should be a 3 letter uppercase ISO 4217 code, e.g EUR")
+	}
+
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return fmt.Errorf("CURRENCY should be a 3 letter uppercase ISO 4217 code, e.g EUR")
+		}
+	}
+
+	if _, err := rates.Rate(pkg.DefaultCurrency, currency); err != nil {
+		return fmt.Errorf("CURRENCY %s is not supported", currency)
+	}
+
+	return nil
+}
+
+func validateRanking(req pkg.QueryParams) error {
+	switch req.DepartureTimePreference {
+	case "", pkg.DepartureTimeMorning, pkg.DepartureTimeAfternoon, pkg.DepartureTimeEvening, pkg.DepartureTimeNight:
+	default:
+		return fmt.Errorf("DEPARTURE_TIME should be one of MORNING, AFTERNOON, EVENING or NIGHT")
+	}
+
+	weights := []struct {
+		name  string
+		value *float64
+	}{
+		{"PRICE_WEIGHT", req.PriceWeight},
+		{"DURATION_WEIGHT", req.DurationWeight},
+		{"STOPS_WEIGHT", req.StopsWeight},
+		{"DEPARTURE_TIME_WEIGHT", req.DepartureTimeWeight},
+		{"EMISSIONS_WEIGHT", req.EmissionsWeight},
+	}
+
+	for _, weight := range weights {
+		if weight.value != nil && *weight.value < 0 {
+			return fmt.Errorf("%s should not be negative", weight.name)
+		}
+	}
+
+	return nil
+}
+
+func validateFilters(req pkg.QueryParams) error {
+	windows := []struct {
+		name  string
+		value string
+	}{
+		{"DEPARTURE_TIME_FROM", req.DepartureTimeFrom},
+		{"DEPARTURE_TIME_TO", req.DepartureTimeTo},
+		{"ARRIVAL_TIME_FROM", req.ArrivalTimeFrom},
+		{"ARRIVAL_TIME_TO", req.ArrivalTimeTo},
+	}
+
+	for _, window := range windows {
+		if window.value == "" {
+			continue
+		}
+
+		if _, err := time.Parse(mapping.TimeOfDayFormat, window.value); err != nil {
+			return fmt.Errorf("%s should be formatted as HH:MM", window.name)
+		}
+	}
+
+	if req.MaxPrice < 0 {
+		return fmt.Errorf("MAX_PRICE should not be negative")
+	}
+
+	if req.MaxDuration < 0 {
+		return fmt.Errorf("MAX_DURATION should not be negative")
+	}
+
+	for _, airline := range req.IncludedAirlines {
+		if slices.Contains(req.ExcludedAirlines, airline) {
+			return fmt.Errorf("INCLUDED_AIRLINES and EXCLUDED_AIRLINES should not overlap")
+		}
+	}
+
+	return nil
+}
+
+func validateSort(req pkg.QueryParams) error {
+	if _, ok := mapping.SortOrders[req.Sort]; req.Sort != "" && !ok {
+		return fmt.Errorf("SORT should be one of CHEAPEST, FASTEST, EARLIEST_DEPARTURE, LATEST_DEPARTURE, EARLIEST_ARRIVAL, FEWEST_STOPS, LOWEST_EMISSIONS or PRICE_PER_HOUR")
+	}
+
+	return nil
+}
+
+func validatePareto(req pkg.QueryParams) error {
+	switch req.Pareto {
+	case "", pkg.ParetoPriceDuration, pkg.ParetoPriceDurationStops:
+	default:
+		return fmt.Errorf("PARETO should be one of PRICE_DURATION or PRICE_DURATION_STOPS")
+	}
+
+	return nil
+}
+
+func validatePagination(req pkg.QueryParams) error {
+	if req.Limit < 0 || req.Limit > 100 {
+		return fmt.Errorf("LIMIT should be between 0 and 100")
+	}
+
+	if req.Cursor != "" && req.Limit == 0 {
+		return fmt.Errorf("LIMIT should not be empty when CURSOR is present")
+	}
+
+	return nil
+}
+
+func validatePassengers(req pkg.QueryParams) error {
+	if req.Adults < 0 || req.Children < 0 || req.InfantsOnLap < 0 || req.InfantsInSeat < 0 || req.Seniors < 0 {
+		return fmt.Errorf("PASSENGERS should not be negative")
+	}
+
+	if req.Adults+req.Seniors == 0 {
+		return fmt.Errorf("ADULTS or SENIORS should not be empty")
+	}
+
+	// every infant must travel with an adult
+	if req.InfantsOnLap+req.InfantsInSeat > req.Adults+req.Seniors {
+		return fmt.Errorf("INFANTS should not exceed ADULTS and SENIORS")
+	}
+
+	// vendors allow up to 9 travelers per search
+	if req.Travelers() > 9 {
+		return fmt.Errorf("PASSENGERS should not exceed 9 travelers")
+	}
+
+	return nil
+}
+
+func validateCabin(req pkg.QueryParams) error {
+	switch req.Cabin {
+	case "", pkg.CabinEconomy, pkg.CabinPremiumEconomy, pkg.CabinBusiness, pkg.CabinFirst:
+		return nil
+	}
+
+	return fmt.Errorf("CABIN should be one of %s, %s, %s or %s", pkg.CabinEconomy, pkg.CabinPremiumEconomy, pkg.CabinBusiness, pkg.CabinFirst)
+}
+
+func validateMaxStops(req pkg.QueryParams) error {
+	if req.MaxStops < pkg.MaxStopsDirect || req.MaxStops > pkg.MaxStopsAny {
+		return fmt.Errorf("MAX_STOPS should be between %d and %d", pkg.MaxStopsDirect, pkg.MaxStopsAny)
+	}
+
+	return nil
+}
+
+func validateConnections(req pkg.QueryParams) error {
+	if req.MinConnectionTime < 0 || req.MaxConnectionTime < 0 {
+		return fmt.Errorf("CONNECTION_TIME should not be negative")
+	}
+
+	if req.MaxConnectionTime > 0 && req.MinConnectionTime > req.MaxConnectionTime {
+		return fmt.Errorf("MIN_CONNECTION_TIME should not exceed MAX_CONNECTION_TIME")
+	}
+
 	return nil
 }
 
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/exchange"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateBestFlightsParamsRequest(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	rates, err := exchange.DefaultFileProvider()()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := pkg.QueryParams{Origin: "NYC", Destination: "LHR", Adults: 1, Date: time.Date(2025, 5, 9, 0, 0, 0, 0, time.UTC)}
+
+	run("Airport pairs are searched on every flexible day", func(t *testing.T) {
+		// 3 airport pairs on 5 days
+		flexible := params
+		flexible.FlexDays = 2
+		assert.NoError(t, validateBestFlightsParamsRequest(rates, flexible))
+	})
+
+	run("Airport pairs and flexible days are limited together", func(t *testing.T) {
+		// 3 airport pairs on 7 days
+		flexible := params
+		flexible.FlexDays = 3
+		assert.EqualError(t, validateBestFlightsParamsRequest(rates, flexible), "ORIGIN and DESTINATION airport pairs should not exceed 16 searches across FLEX_DAYS")
+	})
+
+	run("Airport pairs are limited on their own", func(t *testing.T) {
+		many := params
+		many.Origin = "JFK,LGA,EWR,BOS,IAD"
+		many.Destination = "LHR,LGW,STN,CDG"
+		assert.EqualError(t, validateBestFlightsParamsRequest(rates, many), "ORIGIN and DESTINATION should not expand to more than 16 airport pairs")
+	})
+}
@@ -0,0 +1,140 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// DefaultConfig opens a circuit after 5 consecutive failures, probing the vendor again after 30 seconds
+var DefaultConfig = Config{
+	FailureThreshold: 5,
+	CoolDown:         30 * time.Second,
+}
+
+// Config represents when circuits open, and for how long
+type Config struct {
+	FailureThreshold int           // consecutive failures opening the circuit
+	CoolDown         time.Duration // how long an open circuit skips the vendor before probing it
+}
+
+// Breaker is the circuit breaker of a single vendor, safe for concurrent use
+type Breaker struct {
+	mu       sync.Mutex
+	config   Config
+	now      func() time.Time
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool // a half open circuit only lets a single request through
+}
+
+// Allow reports whether a request to the vendor should be made, every allowed request must be followed by
+// Success, Failure or Release
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case pkg.BreakerOpen:
+		if b.now().Before(b.openedAt.Add(b.config.CoolDown)) {
+			return false
+		}
+
+		b.state = pkg.BreakerHalfOpen
+		b.probing = true
+		return true
+	case pkg.BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success closes the circuit
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = pkg.BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure opens the circuit once the failure threshold is reached, a failed probe opens it right away
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == pkg.BreakerHalfOpen || b.failures >= b.config.FailureThreshold {
+		b.state = pkg.BreakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// Release gives up an allowed request without telling anything about the vendor, e.g the client went away
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// Status returns the current state of the circuit
+func (b *Breaker) Status() pkg.BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := pkg.BreakerStatus{State: b.state, Failures: b.failures}
+	if b.state == pkg.BreakerClosed {
+		return status
+	}
+
+	openedAt := b.openedAt
+	status.OpenedAt = &openedAt
+	if b.state == pkg.BreakerOpen {
+		retryAt := b.openedAt.Add(b.config.CoolDown)
+		status.RetryAt = &retryAt
+	}
+
+	return status
+}
+
+// Breakers holds the circuit breaker of every vendor, by source
+type Breakers struct {
+	mu       sync.Mutex
+	config   Config
+	now      func() time.Time
+	breakers map[string]*Breaker
+}
+
+// New returns the circuit breakers of every vendor, all of them closed
+func New(config Config, now func() time.Time) *Breakers {
+	return &Breakers{
+		config:   config,
+		now:      now,
+		breakers: map[string]*Breaker{},
+	}
+}
+
+// Breaker returns the circuit breaker of a vendor, creating it closed the first time
+func (b *Breakers) Breaker(source string) *Breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	breaker, ok := b.breakers[source]
+	if !ok {
+		breaker = &Breaker{config: b.config, now: b.now, state: pkg.BreakerClosed}
+		b.breakers[source] = breaker
+	}
+
+	return breaker
+}
@@ -0,0 +1,92 @@
+package breaker_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/breaker"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	now := time.Date(2025, 5, 9, 10, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	config := breaker.Config{FailureThreshold: 2, CoolDown: time.Minute}
+
+	run("Circuits open once the threshold is reached", func(t *testing.T) {
+		circuit := breaker.New(config, clock).Breaker(pkg.SourceAmadeus)
+
+		assert.True(t, circuit.Allow())
+		circuit.Failure()
+		assert.Equal(t, pkg.BreakerClosed, circuit.Status().State)
+
+		assert.True(t, circuit.Allow())
+		circuit.Failure()
+		assert.False(t, circuit.Allow())
+
+		status := circuit.Status()
+		assert.Equal(t, pkg.BreakerOpen, status.State)
+		assert.Equal(t, 2, status.Failures)
+		assert.Equal(t, now.Add(time.Minute), *status.RetryAt)
+	})
+
+	run("Successes reset the failures", func(t *testing.T) {
+		circuit := breaker.New(config, clock).Breaker(pkg.SourceAmadeus)
+
+		circuit.Failure()
+		circuit.Success()
+		circuit.Failure()
+		assert.Equal(t, pkg.BreakerClosed, circuit.Status().State)
+		assert.Equal(t, 1, circuit.Status().Failures)
+	})
+
+	run("A single probe is allowed after the cool-down", func(t *testing.T) {
+		current := now
+		circuit := breaker.New(config, func() time.Time { return current }).Breaker(pkg.SourceFlightsky)
+		circuit.Failure()
+		circuit.Failure()
+
+		current = current.Add(time.Minute)
+		assert.True(t, circuit.Allow())
+		assert.False(t, circuit.Allow())
+		assert.Equal(t, pkg.BreakerHalfOpen, circuit.Status().State)
+
+		// released probes let the next request through
+		circuit.Release()
+		assert.True(t, circuit.Allow())
+
+		circuit.Success()
+		assert.Equal(t, pkg.BreakerClosed, circuit.Status().State)
+		assert.True(t, circuit.Allow())
+	})
+
+	run("Failed probes open the circuit again", func(t *testing.T) {
+		current := now
+		circuit := breaker.New(config, func() time.Time { return current }).Breaker(pkg.SourceFlightsky)
+		circuit.Failure()
+		circuit.Failure()
+
+		current = current.Add(2 * time.Minute)
+		assert.True(t, circuit.Allow())
+		circuit.Failure()
+
+		status := circuit.Status()
+		assert.Equal(t, pkg.BreakerOpen, status.State)
+		assert.Equal(t, current, *status.OpenedAt)
+		assert.False(t, circuit.Allow())
+	})
+
+	run("Vendors have their own circuit", func(t *testing.T) {
+		breakers := breaker.New(config, clock)
+		breakers.Breaker(pkg.SourceAmadeus).Failure()
+		breakers.Breaker(pkg.SourceAmadeus).Failure()
+
+		assert.Same(t, breakers.Breaker(pkg.SourceAmadeus), breakers.Breaker(pkg.SourceAmadeus))
+		assert.False(t, breakers.Breaker(pkg.SourceAmadeus).Allow())
+		assert.True(t, breakers.Breaker(pkg.SourceFlightsky).Allow())
+	})
+}
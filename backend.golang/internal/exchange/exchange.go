@@ -0,0 +1,113 @@
+package exchange
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// RateProvider provides exchange rates between currencies
+type RateProvider interface {
+	// Rate returns how much one unit of the from currency is worth in the to currency
+	Rate(from, to string) (float64, error)
+}
+
+// ProviderFunc exchange rate provider factory
+type ProviderFunc func() (RateProvider, error)
+
+//go:embed rates.json
+var defaultRates []byte
+
+// FileProvider provides exchange rates relative to a base currency, loaded from a json file
+// It is meant for offline use, so rates are only as fresh as the file itself
+type FileProvider struct {
+	Base  string             `json:"base"`
+	Date  string             `json:"date"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// DefaultFileProvider loads exchange rates from the file at EXCHANGE_RATES_FILE,
+// falling back to the snapshot embedded in the binary when it's not set
+func DefaultFileProvider() ProviderFunc {
+	return func() (RateProvider, error) {
+		path := os.Getenv("EXCHANGE_RATES_FILE")
+		if path == "" {
+			return newFileProvider(defaultRates)
+		}
+
+		return NewFileProvider(path)
+	}
+}
+
+// NewFileProvider loads exchange rates from a json file
+func NewFileProvider(path string) (FileProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return FileProvider{}, errors.Wrap(err, "exchange - unable to read rates file")
+	}
+
+	return newFileProvider(data)
+}
+
+func newFileProvider(data []byte) (FileProvider, error) {
+	var provider FileProvider
+	if err := json.Unmarshal(data, &provider); err != nil {
+		return FileProvider{}, errors.Wrap(err, "exchange - unable to decode rates file")
+	}
+
+	return provider, nil
+}
+
+// Rate returns the exchange rate between two currencies, crossing through the base currency when needed
+func (p FileProvider) Rate(from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	fromRate, err := p.baseRate(from)
+	if err != nil {
+		return 0, err
+	}
+
+	toRate, err := p.baseRate(to)
+	if err != nil {
+		return 0, err
+	}
+
+	return toRate / fromRate, nil
+}
+
+func (p FileProvider) baseRate(currency string) (float64, error) {
+	if currency == p.Base {
+		return 1, nil
+	}
+
+	rate, ok := p.Rates[currency]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("exchange - no exchange rate available for %s", currency)
+	}
+
+	return rate, nil
+}
+
+// Convert converts an amount to another currency, rounded to cents
+func Convert(provider RateProvider, amount pkg.Amount, currency string) (pkg.Amount, error) {
+	if amount.Currency == currency {
+		return amount, nil
+	}
+
+	rate, err := provider.Rate(amount.Currency, currency)
+	if err != nil {
+		return pkg.Amount{}, err
+	}
+
+	return pkg.Amount{
+		Value:    math.Round(amount.Value*rate*100) / 100,
+		Currency: currency,
+	}, nil
+}
@@ -0,0 +1,80 @@
+package exchange_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/exchange"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileProviderRate(t *testing.T) {
+	provider, err := exchange.NewFileProvider(filepath.Join("testdata", "rates.json"))
+
+	run := testhelpers.Run(t)
+
+	run("No errors", func(t *testing.T) {
+		assert.NoError(t, err)
+	})
+
+	run("Same currency", func(t *testing.T) {
+		rate, err := provider.Rate("EUR", "EUR")
+		assert.NoError(t, err)
+		assert.Equal(t, 1.0, rate)
+	})
+
+	run("From base currency", func(t *testing.T) {
+		rate, err := provider.Rate("USD", "EUR")
+		assert.NoError(t, err)
+		assert.Equal(t, 0.8, rate)
+	})
+
+	run("Cross rate through base currency", func(t *testing.T) {
+		rate, err := provider.Rate("EUR", "GBP")
+		assert.NoError(t, err)
+		assert.InDelta(t, 0.625, rate, 0.000001)
+	})
+
+	run("Unknown currency", func(t *testing.T) {
+		_, err := provider.Rate("USD", "XXX")
+		assert.Error(t, err)
+	})
+}
+
+func TestConvert(t *testing.T) {
+	provider, err := exchange.NewFileProvider(filepath.Join("testdata", "rates.json"))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	run := testhelpers.Run(t)
+
+	run("Converted and rounded to cents", func(t *testing.T) {
+		amount, err := exchange.Convert(provider, pkg.Amount{Value: 405.99, Currency: "USD"}, "EUR")
+		assert.NoError(t, err)
+		assert.Equal(t, pkg.Amount{Value: 324.79, Currency: "EUR"}, amount)
+	})
+
+	run("Same currency is kept as is", func(t *testing.T) {
+		amount, err := exchange.Convert(provider, pkg.Amount{Value: 405.99, Currency: "USD"}, "USD")
+		assert.NoError(t, err)
+		assert.Equal(t, pkg.Amount{Value: 405.99, Currency: "USD"}, amount)
+	})
+}
+
+func TestDefaultFileProvider(t *testing.T) {
+	t.Setenv("EXCHANGE_RATES_FILE", "")
+	provider, err := exchange.DefaultFileProvider()()
+
+	run := testhelpers.Run(t)
+
+	run("Embedded rates are loaded", func(t *testing.T) {
+		assert.NoError(t, err)
+		rate, err := provider.Rate("USD", "EUR")
+		assert.NoError(t, err)
+		assert.Greater(t, rate, 0.0)
+	})
+}
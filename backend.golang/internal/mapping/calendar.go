@@ -0,0 +1,128 @@
+package mapping
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/amadeus"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/googleflights"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// AmadeusToPkgCalendarDays maps Amadeus flight dates to generic pkg calendar days
+func AmadeusToPkgCalendarDays(c chan error, dates []amadeus.FlightDate, currency string, retrievedAt time.Time) []pkg.CalendarDay {
+	results := []pkg.CalendarDay{}
+	for _, date := range dates {
+		departureDate, err := time.Parse("2006-01-02", date.DepartureDate)
+		if err != nil {
+			c <- err
+			return []pkg.CalendarDay{}
+		}
+
+		price, err := strconv.ParseFloat(date.Price.Total, 64)
+		if err != nil {
+			c <- err
+			return []pkg.CalendarDay{}
+		}
+
+		results = append(results, pkg.CalendarDay{
+			Date: departureDate,
+			Price: &pkg.Amount{
+				Value:    price,
+				Currency: vendorCurrency(currency),
+			},
+			Source:      pkg.SourceAmadeus,
+			Freshness:   pkg.FreshnessLive,
+			RetrievedAt: &retrievedAt,
+		})
+	}
+
+	return results
+}
+
+// GoogleflightsToPkgLowestFare maps google flights price insights to the lowest fare google knows for a search,
+// it reports false when google has no insights for the search
+func GoogleflightsToPkgLowestFare(gflights googleflights.FlightOffer) (pkg.Amount, bool) {
+	if gflights.PriceInsights.LowestPrice <= 0 {
+		return pkg.Amount{}, false
+	}
+
+	return pkg.Amount{
+		Value:    float64(gflights.PriceInsights.LowestPrice),
+		Currency: vendorCurrency(gflights.Currency),
+	}, true
+}
+
+// NewFareCalendarDay records a fare quoted by a vendor while searching as a calendar day
+func NewFareCalendarDay(source string, date time.Time, retrievedAt time.Time, price pkg.Amount) pkg.CalendarDay {
+	return pkg.CalendarDay{
+		Date:        date,
+		Price:       &price,
+		Source:      source,
+		Freshness:   pkg.FreshnessCached,
+		RetrievedAt: &retrievedAt,
+	}
+}
+
+// NewLowestFareCalendarDay records the cheapest of the flight offers found by a vendor as a calendar day,
+// it reports false when there are no offers to pick from
+func NewLowestFareCalendarDay(source string, date time.Time, retrievedAt time.Time, flights ...pkg.FlightOffer) (pkg.CalendarDay, bool) {
+	if len(flights) == 0 {
+		return pkg.CalendarDay{}, false
+	}
+
+	lowest := flights[0].Price
+	for _, flight := range flights[1:] {
+		if flight.Price.Value < lowest.Value {
+			lowest = flight.Price
+		}
+	}
+
+	return NewFareCalendarDay(source, date, retrievedAt, lowest), true
+}
+
+// NewCalendarResponse builds the price calendar for a month, keeping the lowest known fare per day
+// Days without any known fare are still included, so clients are able to render the whole month
+func NewCalendarResponse(params pkg.CalendarQueryParams, firstDay time.Time, fares ...pkg.CalendarDay) pkg.GetCalendarResponse {
+	lowest := map[string]pkg.CalendarDay{}
+	for _, fare := range fares {
+		if fare.Price == nil {
+			continue
+		}
+
+		key := fare.Date.Format("2006-01-02")
+		current, ok := lowest[key]
+		if !ok || fare.Price.Value < current.Price.Value ||
+			// on a tie, the freshest fare wins
+			(fare.Price.Value == current.Price.Value && isFresher(fare, current)) {
+			lowest[key] = fare
+		}
+	}
+
+	response := pkg.GetCalendarResponse{
+		Origin:      params.Origin,
+		Destination: params.Destination,
+		Month:       params.Month,
+		Days:        []pkg.CalendarDay{},
+	}
+
+	for day := firstDay; day.Month() == firstDay.Month(); day = day.AddDate(0, 0, 1) {
+		fare, ok := lowest[day.Format("2006-01-02")]
+		if !ok {
+			fare = pkg.CalendarDay{}
+		}
+
+		fare.Date = day
+		response.Days = append(response.Days, fare)
+	}
+
+	return response
+}
+
+func isFresher(a, b pkg.CalendarDay) bool {
+	if a.RetrievedAt == nil {
+		return false
+	}
+
+	return b.RetrievedAt == nil || a.RetrievedAt.After(*b.RetrievedAt)
+}
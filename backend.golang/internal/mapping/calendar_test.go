@@ -0,0 +1,90 @@
+package mapping_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/amadeus"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCalendarResponse(t *testing.T) {
+	var amadeusResp amadeus.APIResponse
+	testhelpers.FileToStruct(t, filepath.Join("testdata", "amadeus-flight-dates.json"), &amadeusResp)
+
+	var amadeusDates []amadeus.FlightDate
+	if err := json.Unmarshal(amadeusResp.Data, &amadeusDates); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	retrievedAt := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	recordedAt := time.Date(2025, 4, 30, 12, 0, 0, 0, time.UTC)
+	firstDay := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	fares := mapping.AmadeusToPkgCalendarDays(make(chan error), amadeusDates, amadeusResp.Meta.Currency, retrievedAt)
+	fares = append(fares,
+		// cheaper than amadeus
+		pkg.CalendarDay{
+			Date:        time.Date(2025, 5, 9, 0, 0, 0, 0, time.UTC),
+			Price:       &pkg.Amount{Value: 399.99, Currency: "USD"},
+			Source:      pkg.SourceGoogleflights,
+			Freshness:   pkg.FreshnessCached,
+			RetrievedAt: &recordedAt,
+		},
+		// more expensive than amadeus
+		pkg.CalendarDay{
+			Date:        time.Date(2025, 5, 10, 0, 0, 0, 0, time.UTC),
+			Price:       &pkg.Amount{Value: 420, Currency: "USD"},
+			Source:      pkg.SourceFlightsky,
+			Freshness:   pkg.FreshnessCached,
+			RetrievedAt: &recordedAt,
+		},
+		// not covered by amadeus
+		pkg.CalendarDay{
+			Date:        time.Date(2025, 5, 20, 0, 0, 0, 0, time.UTC),
+			Price:       &pkg.Amount{Value: 350.5, Currency: "USD"},
+			Source:      pkg.SourceFlightsky,
+			Freshness:   pkg.FreshnessCached,
+			RetrievedAt: &recordedAt,
+		},
+	)
+
+	actual := mapping.NewCalendarResponse(pkg.CalendarQueryParams{
+		Origin:      "SYD",
+		Destination: "BKK",
+		Month:       "2025-05",
+	}, firstDay, fares...)
+
+	run := testhelpers.Run(t)
+
+	run("Every day of the month is present", func(t *testing.T) {
+		assert.Len(t, actual.Days, 31)
+		for i, day := range actual.Days {
+			assert.Equal(t, firstDay.AddDate(0, 0, i), day.Date)
+		}
+	})
+
+	run("Lowest fare per day is kept", func(t *testing.T) {
+		assert.Equal(t, 399.99, actual.Days[8].Price.Value)
+		assert.Equal(t, pkg.SourceGoogleflights, actual.Days[8].Source)
+		assert.Equal(t, pkg.FreshnessCached, actual.Days[8].Freshness)
+
+		assert.Equal(t, 389.10, actual.Days[9].Price.Value)
+		assert.Equal(t, pkg.SourceAmadeus, actual.Days[9].Source)
+		assert.Equal(t, pkg.FreshnessLive, actual.Days[9].Freshness)
+
+		assert.Equal(t, 455.0, actual.Days[13].Price.Value)
+		assert.Equal(t, 350.5, actual.Days[19].Price.Value)
+	})
+
+	run("Days without fares have no price", func(t *testing.T) {
+		assert.Nil(t, actual.Days[0].Price)
+		assert.Empty(t, actual.Days[0].Source)
+	})
+}
@@ -0,0 +1,66 @@
+package mapping
+
+import (
+	"github.com/rubengp99/golang-flights-challenge/internal/exchange"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// ConvertFlightOffers quotes every flight offer in the same currency, so vendors pricing in different ones can be compared
+func ConvertFlightOffers(rates exchange.RateProvider, currency string, flights ...pkg.FlightOffer) ([]pkg.FlightOffer, error) {
+	results := make([]pkg.FlightOffer, 0, len(flights))
+	for _, flight := range flights {
+		price, err := exchange.Convert(rates, flight.Price, currency)
+		if err != nil {
+			return nil, err
+		}
+
+		flight.Price = price
+		if len(flight.TravelerPricings) > 0 {
+			travelerPricings := make([]pkg.TravelerPricing, 0, len(flight.TravelerPricings))
+			for _, traveler := range flight.TravelerPricings {
+				traveler.Price, err = exchange.Convert(rates, traveler.Price, currency)
+				if err != nil {
+					return nil, err
+				}
+
+				travelerPricings = append(travelerPricings, traveler)
+			}
+			flight.TravelerPricings = travelerPricings
+		}
+
+		if len(flight.Prices) > 0 {
+			prices := make([]pkg.VendorPrice, 0, len(flight.Prices))
+			for _, vendorPrice := range flight.Prices {
+				vendorPrice.Price, err = exchange.Convert(rates, vendorPrice.Price, currency)
+				if err != nil {
+					return nil, err
+				}
+
+				prices = append(prices, vendorPrice)
+			}
+			flight.Prices = prices
+		}
+
+		results = append(results, flight)
+	}
+
+	return results, nil
+}
+
+// ConvertCalendarDays quotes every calendar fare in the same currency, days without a known fare are kept as they are
+func ConvertCalendarDays(rates exchange.RateProvider, currency string, days ...pkg.CalendarDay) ([]pkg.CalendarDay, error) {
+	results := make([]pkg.CalendarDay, 0, len(days))
+	for _, day := range days {
+		if day.Price != nil {
+			price, err := exchange.Convert(rates, *day.Price, currency)
+			if err != nil {
+				return nil, err
+			}
+			day.Price = &price
+		}
+
+		results = append(results, day)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,104 @@
+package mapping_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/exchange"
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/amadeus"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/flightsky"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertFlightOffers(t *testing.T) {
+	rates, err := exchange.NewFileProvider(filepath.Join("testdata", "rates.json"))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	var amadeusFlights amadeus.APIResponse
+	testhelpers.FileToStruct(t, filepath.Join("testdata", "amadeus-offers.json"), &amadeusFlights)
+
+	var amadeusOffers []amadeus.FlightOffer
+	if err := json.Unmarshal(amadeusFlights.Data, &amadeusOffers); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	var flightskyResp flightsky.APIResponse
+	testhelpers.FileToStruct(t, filepath.Join("testdata", "flightsky-offers.json"), &flightskyResp)
+
+	var flightskyOffers flightsky.FlightOffer
+	if err := json.Unmarshal(flightskyResp.Data, &flightskyOffers); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	// flights sky quotes in the currency it was asked for
+	flightskyOffers.Currency = "EUR"
+
+	flights := mapping.AmadeusToPkgFlights(make(chan error), amadeusOffers, nil)
+	flights = append(flights, mapping.FlightskyToPkgFlights(make(chan error), flightskyOffers)...)
+
+	run := testhelpers.Run(t)
+
+	run("Vendor currencies are kept on mapping", func(t *testing.T) {
+		assert.Equal(t, "USD", flights[0].Price.Currency)
+		assert.Equal(t, "EUR", flights[len(flights)-1].Price.Currency)
+	})
+
+	actual, err := mapping.ConvertFlightOffers(rates, "EUR", flights...)
+
+	run("No errors", func(t *testing.T) {
+		assert.NoError(t, err)
+	})
+
+	run("Every price is quoted in the requested currency", func(t *testing.T) {
+		assert.Len(t, actual, len(flights))
+		for i, offer := range actual {
+			assert.Equal(t, "EUR", offer.Price.Currency)
+			for _, traveler := range offer.TravelerPricings {
+				assert.Equal(t, "EUR", traveler.Price.Currency)
+			}
+
+			if flights[i].Price.Currency == "EUR" {
+				assert.Equal(t, flights[i].Price.Value, offer.Price.Value)
+			} else {
+				assert.InDelta(t, flights[i].Price.Value*0.8, offer.Price.Value, 0.005)
+			}
+		}
+	})
+
+	run("Unknown currency", func(t *testing.T) {
+		_, err := mapping.ConvertFlightOffers(rates, "XXX", flights...)
+		assert.Error(t, err)
+	})
+}
+
+func TestConvertCalendarDays(t *testing.T) {
+	rates, err := exchange.NewFileProvider(filepath.Join("testdata", "rates.json"))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	actual, err := mapping.ConvertCalendarDays(rates, "GBP",
+		pkg.CalendarDay{Price: &pkg.Amount{Value: 100, Currency: "EUR"}},
+		pkg.CalendarDay{},
+	)
+
+	run := testhelpers.Run(t)
+
+	run("No errors", func(t *testing.T) {
+		assert.NoError(t, err)
+	})
+
+	run("Fares are converted and days without fares are kept", func(t *testing.T) {
+		assert.Equal(t, &pkg.Amount{Value: 62.5, Currency: "GBP"}, actual[0].Price)
+		assert.Nil(t, actual[1].Price)
+	})
+}
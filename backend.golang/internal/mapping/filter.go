@@ -0,0 +1,238 @@
+package mapping
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// TimeOfDayFormat is the format of the departure and arrival time windows
+const TimeOfDayFormat = "15:04"
+
+// FilterFlightOffers removes flight offers not matching the search criteria
+// Vendors are not always able to apply these restrictions themselves, so we enforce them after mapping
+func FilterFlightOffers(params pkg.QueryParams, flights ...pkg.FlightOffer) []pkg.FlightOffer {
+	results := []pkg.FlightOffer{}
+	for _, flight := range flights {
+		if !withinMaxStops(params.MaxStops, flight) {
+			continue
+		}
+
+		if !withinConnections(params, flight) {
+			continue
+		}
+
+		if !withinAirlines(params, flight) {
+			continue
+		}
+
+		if !withinTimeOfDay(params.DepartureTimeFrom, params.DepartureTimeTo, flight.Departure.Timestamp) ||
+			!withinTimeOfDay(params.ArrivalTimeFrom, params.ArrivalTimeTo, flight.Arrival.Timestamp) {
+			continue
+		}
+
+		if params.MaxPrice > 0 && flight.Price.Value > params.MaxPrice {
+			continue
+		}
+
+		if params.MaxDuration > 0 && flight.TotalDurationInMinutes() > float64(params.MaxDuration) {
+			continue
+		}
+
+		results = append(results, flight)
+	}
+
+	return results
+}
+
+func withinMaxStops(maxStops int, flight pkg.FlightOffer) bool {
+	if maxStops >= pkg.MaxStopsAny {
+		return true
+	}
+
+	if flight.Layovers > maxStops {
+		return false
+	}
+
+	for _, itinerary := range flight.Itineraries {
+		if itinerary.Layovers > maxStops {
+			return false
+		}
+	}
+
+	return true
+}
+
+func withinConnections(params pkg.QueryParams, flight pkg.FlightOffer) bool {
+	itineraries := flight.Itineraries
+	if len(itineraries) == 0 {
+		itineraries = []pkg.Itinerary{flight.Itinerary}
+	}
+
+	for _, itinerary := range itineraries {
+		for _, connection := range itinerary.Connections {
+			if params.MinConnectionTime > 0 && connection.DurationInMinutes < float64(params.MinConnectionTime) {
+				return false
+			}
+
+			if params.MaxConnectionTime > 0 && connection.DurationInMinutes > float64(params.MaxConnectionTime) {
+				return false
+			}
+
+			if slices.Contains(params.ExcludedConnectionAirports, connection.Airport) ||
+				slices.Contains(params.ExcludedConnectionAirports, connection.DepartureAirport) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// withinAirlines checks the airlines marketing every segment, offers with any segment sold by an excluded airline are removed,
+// as well as offers with any segment not sold by an included one
+func withinAirlines(params pkg.QueryParams, flight pkg.FlightOffer) bool {
+	for _, itinerary := range flight.Bounds() {
+		for _, segment := range itinerary.Segments {
+			code := segment.MarketingCarrier.Code
+			if len(params.IncludedAirlines) > 0 && !slices.Contains(params.IncludedAirlines, code) {
+				return false
+			}
+
+			if slices.Contains(params.ExcludedAirlines, code) || slices.Contains(params.ExcludedAirlines, segment.OperatingCarrier.Code) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// withinTimeOfDay checks a local time is within a time window, windows ending before they start wrap around midnight, e.g 22:00 to 06:00
+func withinTimeOfDay(from, to string, at time.Time) bool {
+	if from == "" && to == "" {
+		return true
+	}
+
+	minutes := at.Hour()*60 + at.Minute()
+	start, end := 0, 24*60
+	if t, err := time.Parse(TimeOfDayFormat, from); err == nil {
+		start = t.Hour()*60 + t.Minute()
+	}
+
+	if t, err := time.Parse(TimeOfDayFormat, to); err == nil {
+		end = t.Hour()*60 + t.Minute()
+	}
+
+	if start <= end {
+		return minutes >= start && minutes <= end
+	}
+
+	return minutes >= start || minutes <= end
+}
+
+// NewFilterStats summarizes airlines, prices and durations available among flight offers
+func NewFilterStats(flights ...pkg.FlightOffer) pkg.FilterStats {
+	stats := pkg.FilterStats{Airlines: []pkg.AirlineStats{}}
+	airlines := map[string]int{}
+	for i, flight := range flights {
+		if i == 0 || flight.Price.Value < stats.Price.Min.Value {
+			stats.Price.Min = flight.Price
+		}
+
+		if i == 0 || flight.Price.Value > stats.Price.Max.Value {
+			stats.Price.Max = flight.Price
+		}
+
+		duration := flight.TotalDurationInMinutes()
+		if i == 0 || duration < stats.Duration.Min {
+			stats.Duration.Min = duration
+		}
+
+		if i == 0 || duration > stats.Duration.Max {
+			stats.Duration.Max = duration
+		}
+
+		// an offer counts once per airline, no matter how many of its segments are sold by it
+		counted := map[string]bool{}
+		for _, itinerary := range flight.Bounds() {
+			for _, segment := range itinerary.Segments {
+				carrier := segment.MarketingCarrier
+				if carrier.Code == "" || counted[carrier.Code] {
+					continue
+				}
+				counted[carrier.Code] = true
+
+				j, ok := airlines[carrier.Code]
+				if !ok {
+					airlines[carrier.Code] = len(stats.Airlines)
+					stats.Airlines = append(stats.Airlines, pkg.AirlineStats{Carrier: carrier, LowestPrice: flight.Price})
+					j = len(stats.Airlines) - 1
+				}
+
+				stats.Airlines[j].Offers++
+				if flight.Price.Value < stats.Airlines[j].LowestPrice.Value {
+					stats.Airlines[j].LowestPrice = flight.Price
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(stats.Airlines, func(i, j int) bool {
+		return stats.Airlines[i].LowestPrice.Value < stats.Airlines[j].LowestPrice.Value
+	})
+
+	return stats
+}
+
+// MergeFilterStats combines the filter stats of several searches, e.g every day of a flexible date search
+func MergeFilterStats(stats ...pkg.FilterStats) pkg.FilterStats {
+	result := pkg.FilterStats{Airlines: []pkg.AirlineStats{}}
+	airlines := map[string]int{}
+	first := true
+	for _, s := range stats {
+		// searches without offers have nothing to contribute
+		if len(s.Airlines) == 0 && s.Price.Max.Value == 0 {
+			continue
+		}
+
+		if first || s.Price.Min.Value < result.Price.Min.Value {
+			result.Price.Min = s.Price.Min
+		}
+
+		if first || s.Price.Max.Value > result.Price.Max.Value {
+			result.Price.Max = s.Price.Max
+		}
+
+		if first || s.Duration.Min < result.Duration.Min {
+			result.Duration.Min = s.Duration.Min
+		}
+
+		if first || s.Duration.Max > result.Duration.Max {
+			result.Duration.Max = s.Duration.Max
+		}
+		first = false
+
+		for _, airline := range s.Airlines {
+			i, ok := airlines[airline.Code]
+			if !ok {
+				airlines[airline.Code] = len(result.Airlines)
+				result.Airlines = append(result.Airlines, airline)
+				continue
+			}
+
+			result.Airlines[i].Offers += airline.Offers
+			if airline.LowestPrice.Value < result.Airlines[i].LowestPrice.Value {
+				result.Airlines[i].LowestPrice = airline.LowestPrice
+			}
+		}
+	}
+
+	sort.SliceStable(result.Airlines, func(i, j int) bool {
+		return result.Airlines[i].LowestPrice.Value < result.Airlines[j].LowestPrice.Value
+	})
+
+	return result
+}
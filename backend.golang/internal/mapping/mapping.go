@@ -1,8 +1,8 @@
 package mapping
 
 import (
-	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/rubengp99/golang-flights-challenge/internal/vendors/amadeus"
@@ -19,51 +19,124 @@ const (
 // GoogleflightsToPkgFlights maps google flights response format to a generic pkg flight offer one
 func GoogleflightsToPkgFlights(c chan error, gflights googleflights.FlightOffer) []pkg.FlightOffer {
 	results := []pkg.FlightOffer{}
+	currency := vendorCurrency(gflights.Currency)
 
 	itineraries := []googleflights.Itinerary{}
 	itineraries = append(itineraries, gflights.BestFlights...)
 	itineraries = append(itineraries, gflights.OtherFlights...)
 
 	for _, itinerary := range itineraries {
-		for _, flight := range itinerary.Flights {
-			arrivalTime, err := time.Parse(GoogleFlightISO8601TimeFormat, flight.ArrivalAirport.Time)
-			if err != nil {
-				c <- err
-				return []pkg.FlightOffer{}
+		if len(itinerary.Flights) == 0 {
+			// invalid data, ignore
+			continue
+		}
+
+		outbound, err := googleflightsToPkgItinerary(itinerary)
+		if err != nil {
+			c <- err
+			return []pkg.FlightOffer{}
+		}
+
+		// one way offers are priced on the outbound itinerary itself
+		if len(itinerary.ReturnFlights) == 0 {
+			results = append(results, newFlightOffer(pkg.Amount{
+				Value:    itinerary.Price,
+				Currency: currency,
+			}, outbound))
+			continue
+		}
+
+		// on round trips, google prices each return option with the total for both bounds
+		for _, returnItinerary := range itinerary.ReturnFlights {
+			if len(returnItinerary.Flights) == 0 {
+				// invalid data, ignore
+				continue
 			}
 
-			departureTime, err := time.Parse(GoogleFlightISO8601TimeFormat, flight.DepartureAirport.Time)
+			inbound, err := googleflightsToPkgItinerary(returnItinerary)
 			if err != nil {
 				c <- err
 				return []pkg.FlightOffer{}
 			}
 
-			mapped := pkg.FlightOffer{
-				Airline:      flight.Airline,
-				FlightNumber: flight.FlightNumber,
-				Arrival: pkg.Location{
-					Timestamp: arrivalTime,
-					IataCode:  flight.DepartureAirport.ID,
-				},
-				Departure: pkg.Location{
-					Timestamp: departureTime,
-					IataCode:  flight.DepartureAirport.ID,
-				},
-				DurationInMinutes: float64(flight.Duration),
-				Price: pkg.Amount{
-					Value:    itinerary.Price,
-					Currency: "USD",
-				},
-				Layovers: len(itinerary.Layovers),
-			}
-
-			results = append(results, mapped)
+			results = append(results, newFlightOffer(pkg.Amount{
+				Value:    returnItinerary.Price,
+				Currency: currency,
+			}, outbound, inbound))
 		}
 	}
 
 	return results
 }
 
+func googleflightsToPkgItinerary(itinerary googleflights.Itinerary) (pkg.Itinerary, error) {
+	segments := []pkg.Segment{}
+	for _, flight := range itinerary.Flights {
+		departureTime, err := time.Parse(GoogleFlightISO8601TimeFormat, flight.DepartureAirport.Time)
+		if err != nil {
+			return pkg.Itinerary{}, err
+		}
+
+		arrivalTime, err := time.Parse(GoogleFlightISO8601TimeFormat, flight.ArrivalAirport.Time)
+		if err != nil {
+			return pkg.Itinerary{}, err
+		}
+
+		// google reports flight numbers as "TG 476", including the marketing carrier code
+		code, number, found := strings.Cut(flight.FlightNumber, " ")
+		if !found {
+			code, number = "", flight.FlightNumber
+		}
+
+		marketing := pkg.Carrier{Code: code, Name: flight.Airline}
+		operating := marketing
+		if flight.PlaneAndCrewBy != "" {
+			operating = pkg.Carrier{Name: flight.PlaneAndCrewBy}
+		}
+
+		segments = append(segments, pkg.Segment{
+			MarketingCarrier: marketing,
+			OperatingCarrier: operating,
+			FlightNumber:     number,
+			Aircraft:         flight.Airplane,
+			Departure: pkg.Location{
+				Timestamp: departureTime,
+				IataCode:  flight.DepartureAirport.ID,
+			},
+			Arrival: pkg.Location{
+				Timestamp: arrivalTime,
+				IataCode:  flight.ArrivalAirport.ID,
+			},
+			DurationInMinutes: float64(flight.Duration),
+		})
+	}
+
+	// google already reports its layovers, which are more accurate than the ones we derive from segments
+	connections := newConnections(segments, false)
+	if len(connections) == len(itinerary.Layovers) {
+		for i, layover := range itinerary.Layovers {
+			connections[i].DurationInMinutes = float64(layover.Duration)
+			connections[i].Overnight = connections[i].Overnight || layover.Overnight
+		}
+	}
+
+	first := itinerary.Flights[0]
+	return pkg.Itinerary{
+		Airline:      first.Airline,
+		FlightNumber: first.FlightNumber,
+		// the first segment represents the departure time, and the last one, even if it's a single segment, the arrival time
+		Arrival:           segments[len(segments)-1].Arrival,
+		Departure:         segments[0].Departure,
+		DurationInMinutes: float64(itinerary.TotalDuration),
+		Layovers:          len(itinerary.Layovers),
+		// google reports cabins as "Premium economy", so we normalize it to "PREMIUM_ECONOMY"
+		Cabin:            strings.ToUpper(strings.ReplaceAll(first.TravelClass, " ", "_")),
+		Segments:         segments,
+		Connections:      connections,
+		EmissionsInGrams: float64(itinerary.CarbonEmissions.ThisFlight),
+	}, nil
+}
+
 // AmadeusToPkgFlights maps Amadeus flight offers to a generic pkg one
 func AmadeusToPkgFlights(c chan error, aflights []amadeus.FlightOffer, airlines []amadeus.Airline) []pkg.FlightOffer {
 	results := []pkg.FlightOffer{}
@@ -73,69 +146,154 @@ func AmadeusToPkgFlights(c chan error, aflights []amadeus.FlightOffer, airlines
 	}
 
 	for _, offer := range aflights {
+		price, err := strconv.ParseFloat(offer.Price.Total, 64)
+		if err != nil {
+			c <- err
+			return []pkg.FlightOffer{}
+		}
+
+		airlineName := ""
+		if len(offer.ValidatingAirlineCodes) > 0 {
+			code := offer.ValidatingAirlineCodes[0]
+			airlineName = mapAirlines[code].BusinessName
+		}
+
+		// cabins are reported per segment, the first traveler is enough as everyone flies in the same cabin
+		cabins := map[string]string{}
+		if len(offer.TravelerPricings) > 0 {
+			for _, fare := range offer.TravelerPricings[0].FareDetailsBySegment {
+				cabins[fare.SegmentID] = fare.Cabin
+			}
+		}
+
+		// every itinerary is a bound of the same offer, e.g outbound and inbound on round trips
+		itineraries := []pkg.Itinerary{}
 		for _, flight := range offer.Itineraries {
 			if len(flight.Segments) == 0 {
 				// invalid data, ignore
 				continue
 			}
 
-			// the first segment represents the departure time
-			departureTime, err := time.Parse(ISO8601TimeFormat, flight.Segments[0].Departure.At)
+			mapped, err := amadeusToPkgItinerary(flight, airlineName, mapAirlines, cabins)
 			if err != nil {
 				c <- err
 				return []pkg.FlightOffer{}
 			}
 
-			length := len(flight.Segments) - 1
+			itineraries = append(itineraries, mapped)
+		}
 
-			// the last segment, even if it contains a single segment, represents the arrival time
-			arrivalTime, err := time.Parse(ISO8601TimeFormat, flight.Segments[length].Arrival.At)
-			if err != nil {
-				c <- err
-				return []pkg.FlightOffer{}
-			}
+		if len(itineraries) == 0 {
+			// invalid data, ignore
+			continue
+		}
 
-			price, err := strconv.ParseFloat(offer.Price.Total, 64)
+		travelerPricings := []pkg.TravelerPricing{}
+		for _, traveler := range offer.TravelerPricings {
+			travelerPrice, err := strconv.ParseFloat(traveler.Price.Total, 64)
 			if err != nil {
 				c <- err
 				return []pkg.FlightOffer{}
 			}
 
-			airlineName := ""
-			if len(offer.ValidatingAirlineCodes) > 0 {
-				code := offer.ValidatingAirlineCodes[0]
-				airlineName = mapAirlines[code].BusinessName
-			}
-
-			mapped := pkg.FlightOffer{
-				Airline:      airlineName,
-				FlightNumber: flight.Segments[0].Number,
-				Arrival: pkg.Location{
-					Timestamp: arrivalTime,
-					IataCode:  flight.Segments[length].Arrival.IataCode,
-				},
-				Departure: pkg.Location{
-					Timestamp: departureTime,
-					IataCode:  flight.Segments[0].Departure.IataCode,
-				},
-				DurationInMinutes: arrivalTime.Sub(departureTime).Minutes(),
+			travelerPricings = append(travelerPricings, pkg.TravelerPricing{
+				TravelerID:   traveler.TravelerID,
+				TravelerType: traveler.TravelerType,
 				Price: pkg.Amount{
-					Value:    price,
-					Currency: "USD",
+					Value:    travelerPrice,
+					Currency: vendorCurrency(traveler.Price.Currency),
 				},
-				Layovers: len(flight.Segments),
-			}
-
-			results = append(results, mapped)
+			})
 		}
+
+		mapped := newFlightOffer(pkg.Amount{
+			Value:    price,
+			Currency: vendorCurrency(offer.Price.Currency),
+		}, itineraries...)
+		mapped.TravelerPricings = travelerPricings
+
+		results = append(results, mapped)
 	}
 
 	return results
 }
 
+func amadeusToPkgItinerary(flight amadeus.Itinerary, airlineName string, mapAirlines map[string]amadeus.Airline, cabins map[string]string) (pkg.Itinerary, error) {
+	segments := []pkg.Segment{}
+	for _, segment := range flight.Segments {
+		departureTime, err := time.Parse(ISO8601TimeFormat, segment.Departure.At)
+		if err != nil {
+			return pkg.Itinerary{}, err
+		}
+
+		arrivalTime, err := time.Parse(ISO8601TimeFormat, segment.Arrival.At)
+		if err != nil {
+			return pkg.Itinerary{}, err
+		}
+
+		duration, err := parseISO8601Duration(segment.Duration)
+		if err != nil {
+			return pkg.Itinerary{}, err
+		}
+
+		// the operating carrier is only reported when it differs from the marketing one
+		operatingCode := segment.Operating.CarrierCode
+		if operatingCode == "" {
+			operatingCode = segment.CarrierCode
+		}
+
+		segments = append(segments, pkg.Segment{
+			MarketingCarrier: pkg.Carrier{
+				Code: segment.CarrierCode,
+				Name: mapAirlines[segment.CarrierCode].BusinessName,
+			},
+			OperatingCarrier: pkg.Carrier{
+				Code: operatingCode,
+				Name: mapAirlines[operatingCode].BusinessName,
+			},
+			FlightNumber: segment.Number,
+			Aircraft:     segment.Aircraft.Code,
+			Departure: pkg.Location{
+				Timestamp: departureTime,
+				IataCode:  segment.Departure.IataCode,
+				Terminal:  segment.Departure.Terminal,
+			},
+			Arrival: pkg.Location{
+				Timestamp: arrivalTime,
+				IataCode:  segment.Arrival.IataCode,
+				Terminal:  segment.Arrival.Terminal,
+			},
+			DurationInMinutes: duration.Minutes(),
+		})
+	}
+
+	// the first segment represents the departure time, and the last one, even if it's a single segment, the arrival time
+	departure := segments[0].Departure
+	arrival := segments[len(segments)-1].Arrival
+
+	// times are local to each airport, so the duration is taken from the vendor instead of the time in between
+	duration, err := parseISO8601Duration(flight.Duration)
+	if err != nil {
+		return pkg.Itinerary{}, err
+	}
+
+	return pkg.Itinerary{
+		Airline:           airlineName,
+		FlightNumber:      flight.Segments[0].Number,
+		Arrival:           arrival,
+		Departure:         departure,
+		DurationInMinutes: duration.Minutes(),
+		Layovers:          len(segments) - 1,
+		Cabin:             cabins[flight.Segments[0].ID],
+		Segments:          segments,
+		Connections:       newConnections(segments, false),
+	}, nil
+}
+
 // FlightskyToPkgFlights maps flightsky flights format to a generic pkg one
 func FlightskyToPkgFlights(c chan error, fsflights flightsky.FlightOffer) []pkg.FlightOffer {
 	results := []pkg.FlightOffer{}
+	currency := vendorCurrency(fsflights.Currency)
 
 	for _, flight := range fsflights.Itineraries {
 		if len(flight.Legs) == 0 {
@@ -143,72 +301,188 @@ func FlightskyToPkgFlights(c chan error, fsflights flightsky.FlightOffer) []pkg.
 			continue
 		}
 
-		// the first segment represents the departure time
-		departureTime, err := time.Parse(ISO8601TimeFormat, flight.Legs[0].Departure)
-		if err != nil {
-			c <- err
-			return []pkg.FlightOffer{}
+		// every leg is a bound of the same itinerary, e.g outbound and inbound on round trips
+		itineraries := []pkg.Itinerary{}
+		for _, leg := range flight.Legs {
+			if len(leg.Segments) == 0 {
+				// invalid data, ignore
+				break
+			}
+
+			mapped, err := flightskyToPkgItinerary(leg, flight.IsSelfTransfer)
+			if err != nil {
+				c <- err
+				return []pkg.FlightOffer{}
+			}
+
+			itineraries = append(itineraries, mapped)
 		}
 
-		length := len(flight.Legs) - 1
+		if len(itineraries) != len(flight.Legs) {
+			// incomplete itinerary, ignore
+			continue
+		}
 
-		// the last segment, even if it contains a single segment, represents the arrival time
-		arrivalTime, err := time.Parse(ISO8601TimeFormat, flight.Legs[length].Arrival)
+		results = append(results, newFlightOffer(pkg.Amount{
+			Value:    flight.Price.Raw,
+			Currency: currency,
+		}, itineraries...))
+	}
+
+	return results
+}
+
+func flightskyToPkgItinerary(leg flightsky.Flight, selfTransfer bool) (pkg.Itinerary, error) {
+	departureTime, err := time.Parse(ISO8601TimeFormat, leg.Departure)
+	if err != nil {
+		return pkg.Itinerary{}, err
+	}
+
+	arrivalTime, err := time.Parse(ISO8601TimeFormat, leg.Arrival)
+	if err != nil {
+		return pkg.Itinerary{}, err
+	}
+
+	segments := []pkg.Segment{}
+	for _, segment := range leg.Segments {
+		segmentDepartureTime, err := time.Parse(ISO8601TimeFormat, segment.Departure)
 		if err != nil {
-			c <- err
-			return []pkg.FlightOffer{}
+			return pkg.Itinerary{}, err
 		}
 
-		airlineName := ""
-		if len(flight.Legs[0].Segments) > 0 {
-			airlineName = flight.Legs[0].Segments[0].MarketingCarrier.Name
-		} else {
-			// invalid data, ignore
-			continue
+		segmentArrivalTime, err := time.Parse(ISO8601TimeFormat, segment.Arrival)
+		if err != nil {
+			return pkg.Itinerary{}, err
 		}
 
-		mapped := pkg.FlightOffer{
-			Airline:      airlineName,
-			FlightNumber: flight.Legs[0].Segments[0].FlightNumber,
-			Arrival: pkg.Location{
-				Timestamp: arrivalTime,
-				IataCode:  flight.Legs[0].Destination.ID,
-			},
+		segments = append(segments, pkg.Segment{
+			MarketingCarrier: flightskyToPkgCarrier(segment.MarketingCarrier.DisplayCode, segment.MarketingCarrier.AlternateID, segment.MarketingCarrier.Name),
+			OperatingCarrier: flightskyToPkgCarrier(segment.OperatingCarrier.DisplayCode, segment.OperatingCarrier.AlternateID, segment.OperatingCarrier.Name),
+			FlightNumber:     segment.FlightNumber,
 			Departure: pkg.Location{
-				Timestamp: departureTime,
-				IataCode:  flight.Legs[0].Origin.ID,
+				Timestamp: segmentDepartureTime,
+				IataCode:  segment.Origin.DisplayCode,
 			},
-			DurationInMinutes: arrivalTime.Sub(departureTime).Minutes(),
-			Price: pkg.Amount{
-				Value:    flight.Price.Raw,
-				Currency: "USD",
+			Arrival: pkg.Location{
+				Timestamp: segmentArrivalTime,
+				IataCode:  segment.Destination.DisplayCode,
 			},
-			Layovers: len(flight.Legs),
+			DurationInMinutes: float64(segment.DurationInMinutes),
+		})
+	}
+
+	return pkg.Itinerary{
+		Airline:      leg.Segments[0].MarketingCarrier.Name,
+		FlightNumber: leg.Segments[0].FlightNumber,
+		Arrival: pkg.Location{
+			Timestamp: arrivalTime,
+			IataCode:  leg.Destination.ID,
+		},
+		Departure: pkg.Location{
+			Timestamp: departureTime,
+			IataCode:  leg.Origin.ID,
+		},
+		DurationInMinutes: float64(leg.DurationInMinutes), // times are local to each airport
+		Layovers:          leg.StopCount,
+		Segments:          segments,
+		Connections:       newConnections(segments, selfTransfer),
+	}, nil
+}
+
+// flightskyToPkgCarrier prefers the carrier display code, as alternate ids are not always IATA codes, e.g "9~"
+func flightskyToPkgCarrier(displayCode, alternateID, name string) pkg.Carrier {
+	code := displayCode
+	if code == "" {
+		code = alternateID
+	}
+
+	return pkg.Carrier{Code: code, Name: name}
+}
+
+// newConnections derives the layovers of an itinerary out of its consecutive segments
+// self transfers are reported per itinerary, so every connection within it is flagged the same
+func newConnections(segments []pkg.Segment, selfTransfer bool) []pkg.Connection {
+	connections := []pkg.Connection{}
+	for i := 1; i < len(segments); i++ {
+		arrival := segments[i-1].Arrival
+		departure := segments[i].Departure
+
+		connection := pkg.Connection{
+			Airport:           arrival.IataCode,
+			DurationInMinutes: departure.Timestamp.Sub(arrival.Timestamp).Minutes(),
+			Overnight:         arrival.Timestamp.YearDay() != departure.Timestamp.YearDay(),
+			ChangeOfAirport:   arrival.IataCode != departure.IataCode,
+			SelfTransfer:      selfTransfer,
 		}
 
-		results = append(results, mapped)
+		if connection.ChangeOfAirport {
+			connection.DepartureAirport = departure.IataCode
+		}
+
+		connections = append(connections, connection)
 	}
 
-	return results
+	return connections
 }
 
-func NewBestFlightsOffersResponse(flights ...pkg.FlightOffer) pkg.GetBestFlightOffersResponse {
-	// Make independent copies of the flights slice
-	cheapest := append([]pkg.FlightOffer(nil), flights...)
-	fastest := append([]pkg.FlightOffer(nil), flights...)
+// newFlightOffer builds a flight offer out of its bounds, the first one being the outbound flight
+func newFlightOffer(price pkg.Amount, itineraries ...pkg.Itinerary) pkg.FlightOffer {
+	offer := pkg.FlightOffer{
+		Itinerary: itineraries[0],
+		Price:     price,
+	}
 
-	// Sort fastest
-	sort.SliceStable(fastest, func(i, j int) bool {
-		return fastest[i].DurationInMinutes < fastest[j].DurationInMinutes
-	})
+	if len(itineraries) > 1 {
+		offer.Itineraries = itineraries
+	}
 
-	// Sort cheapest
-	sort.SliceStable(cheapest, func(i, j int) bool {
-		return cheapest[i].Price.Value < cheapest[j].Price.Value
-	})
+	return offer
+}
+
+func NewBestFlightsOffersResponse(flights ...pkg.FlightOffer) pkg.GetBestFlightOffersResponse {
+	cheapest := SortFlightOffers(ByPrice, flights...)
+	fastest := SortFlightOffers(ByDuration, flights...)
 
 	return pkg.GetBestFlightOffersResponse{
 		Cheapest: cheapest,
 		Fastest:  fastest,
 	}
 }
+
+// vendorCurrency returns the currency reported by a vendor, vendors not reporting one are assumed to price in our default
+func vendorCurrency(currency string) string {
+	if currency == "" {
+		return pkg.DefaultCurrency
+	}
+
+	return currency
+}
+
+// parseISO8601Duration parses durations as reported by amadeus, e.g "PT9H20M" or "P1DT2H"
+func parseISO8601Duration(value string) (time.Duration, error) {
+	days := 0
+	remaining := strings.TrimPrefix(value, "P")
+	if before, after, found := strings.Cut(remaining, "D"); found {
+		parsed, err := strconv.Atoi(before)
+		if err != nil {
+			return 0, err
+		}
+
+		days, remaining = parsed, after
+	}
+
+	duration := time.Duration(days) * 24 * time.Hour
+
+	// time.ParseDuration understands "9h20m", so we only need to lowercase the time designators
+	remaining = strings.ToLower(strings.TrimPrefix(remaining, "T"))
+	if remaining == "" {
+		return duration, nil
+	}
+
+	parsed, err := time.ParseDuration(remaining)
+	if err != nil {
+		return 0, err
+	}
+
+	return duration + parsed, nil
+}
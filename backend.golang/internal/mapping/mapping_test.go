@@ -2,6 +2,7 @@ package mapping_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"path/filepath"
 	"testing"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/rubengp99/golang-flights-challenge/internal/vendors/googleflights"
 	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
 	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestAmadeusToPkgFlights(t *testing.T) {
@@ -39,6 +41,45 @@ func TestAmadeusToPkgFlights(t *testing.T) {
 	run("Parsed pkg response is as expected", func(t *testing.T) {
 		testhelpers.AssertJSONEquals(t, filepath.Join("testdata", "amadeus-offers-pkg-expected.json"), actual)
 	})
+	run("Itineraries last at least as long as their flights", func(t *testing.T) {
+		for _, offer := range actual {
+			for _, bound := range offer.Bounds() {
+				flying := 0.0
+				for _, segment := range bound.Segments {
+					flying += segment.DurationInMinutes
+				}
+				assert.GreaterOrEqual(t, bound.DurationInMinutes, flying, bound.FlightNumber)
+			}
+		}
+	})
+}
+
+func TestAmadeusRoundTripToPkgFlights(t *testing.T) {
+	var amadeusFlights amadeus.APIResponse
+	testhelpers.FileToStruct(t, filepath.Join("testdata", "amadeus-roundtrip-offers.json"), &amadeusFlights)
+
+	var amadeusOffers []amadeus.FlightOffer
+	if err := json.Unmarshal(amadeusFlights.Data, &amadeusOffers); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	var amadeusAirlinesResp amadeus.APIResponse
+	testhelpers.FileToStruct(t, filepath.Join("testdata", "amadeus-airlines.json"), &amadeusAirlinesResp)
+
+	var amadeusAirlines []amadeus.Airline
+	if err := json.Unmarshal(amadeusAirlinesResp.Data, &amadeusAirlines); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	actual := mapping.AmadeusToPkgFlights(make(chan error), amadeusOffers, amadeusAirlines)
+
+	run := testhelpers.Run(t)
+
+	run("Parsed pkg response is as expected", func(t *testing.T) {
+		testhelpers.AssertJSONEquals(t, filepath.Join("testdata", "amadeus-roundtrip-offers-pkg-expected.json"), actual)
+	})
 }
 
 func TestGoogleflightsToPkgFlights(t *testing.T) {
@@ -71,6 +112,17 @@ func TestFlightskyToPkgFlights(t *testing.T) {
 	run("Parsed pkg response is as expected", func(t *testing.T) {
 		testhelpers.AssertJSONEquals(t, filepath.Join("testdata", "flightsky-offers-pkg-expected.json"), actual)
 	})
+	run("Itineraries last at least as long as their flights", func(t *testing.T) {
+		for _, offer := range actual {
+			for _, bound := range offer.Bounds() {
+				flying := 0.0
+				for _, segment := range bound.Segments {
+					flying += segment.DurationInMinutes
+				}
+				assert.GreaterOrEqual(t, bound.DurationInMinutes, flying, bound.FlightNumber)
+			}
+		}
+	})
 }
 
 func TestNewBestFlightsOffersResponse(t *testing.T) {
@@ -123,3 +175,181 @@ func TestNewBestFlightsOffersResponse(t *testing.T) {
 		testhelpers.AssertJSONEquals(t, filepath.Join("testdata", "best-flight-offers-pkg-expected.json"), actual)
 	})
 }
+
+func TestFilterFlightOffersByMaxStops(t *testing.T) {
+	var flightskyFlights flightsky.APIResponse
+	testhelpers.FileToStruct(t, filepath.Join("testdata", "flightsky-offers.json"), &flightskyFlights)
+
+	var flightskyOffers flightsky.FlightOffer
+	if err := json.Unmarshal(flightskyFlights.Data, &flightskyOffers); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	flightskyList := mapping.FlightskyToPkgFlights(make(chan error), flightskyOffers)
+
+	run := testhelpers.Run(t)
+
+	run("Any number of stops keeps every offer", func(t *testing.T) {
+		actual := mapping.FilterFlightOffers(pkg.QueryParams{MaxStops: pkg.MaxStopsAny}, flightskyList...)
+		assert.Len(t, actual, len(flightskyList))
+	})
+
+	for _, maxStops := range []int{pkg.MaxStopsDirect, pkg.MaxStopsOne} {
+		run(fmt.Sprintf("No offer exceeds %d stops", maxStops), func(t *testing.T) {
+			actual := mapping.FilterFlightOffers(pkg.QueryParams{MaxStops: maxStops}, flightskyList...)
+			assert.Less(t, len(actual), len(flightskyList))
+			for _, offer := range actual {
+				assert.LessOrEqual(t, offer.Layovers, maxStops)
+			}
+		})
+	}
+}
+
+func TestFilterFlightOffersByConnections(t *testing.T) {
+	var googleflightsFlights googleflights.APIResponse
+	testhelpers.FileToStruct(t, filepath.Join("testdata", "googleflights-offers.json"), &googleflightsFlights)
+
+	googleflightsList := mapping.GoogleflightsToPkgFlights(make(chan error), googleflightsFlights.FlightOffer)
+
+	run := testhelpers.Run(t)
+
+	run("Connections within the connection time window", func(t *testing.T) {
+		actual := mapping.FilterFlightOffers(pkg.QueryParams{
+			MaxStops:          pkg.MaxStopsAny,
+			MinConnectionTime: 90,
+			MaxConnectionTime: 180,
+		}, googleflightsList...)
+
+		// 3 direct flights, plus the ones connecting in SIN and CAN
+		assert.Len(t, actual, 5)
+		for _, offer := range actual {
+			for _, connection := range offer.Connections {
+				assert.GreaterOrEqual(t, connection.DurationInMinutes, 90.0)
+				assert.LessOrEqual(t, connection.DurationInMinutes, 180.0)
+			}
+		}
+	})
+
+	run("Excluded connection airports", func(t *testing.T) {
+		actual := mapping.FilterFlightOffers(pkg.QueryParams{
+			MaxStops:                   pkg.MaxStopsAny,
+			ExcludedConnectionAirports: []string{"SIN", "HAK"},
+		}, googleflightsList...)
+
+		assert.Len(t, actual, 5)
+		for _, offer := range actual {
+			for _, connection := range offer.Connections {
+				assert.NotContains(t, []string{"SIN", "HAK"}, connection.Airport)
+			}
+		}
+	})
+}
+
+func TestFilterFlightOffersByResultFilters(t *testing.T) {
+	var googleflightsFlights googleflights.APIResponse
+	testhelpers.FileToStruct(t, filepath.Join("testdata", "googleflights-offers.json"), &googleflightsFlights)
+
+	googleflightsList := mapping.GoogleflightsToPkgFlights(make(chan error), googleflightsFlights.FlightOffer)
+
+	run := testhelpers.Run(t)
+
+	run("Included airlines", func(t *testing.T) {
+		actual := mapping.FilterFlightOffers(pkg.QueryParams{
+			MaxStops:         pkg.MaxStopsAny,
+			IncludedAirlines: []string{"TG", "QF"},
+		}, googleflightsList...)
+
+		// QF connecting with 3K is not included
+		assert.Len(t, actual, 3)
+	})
+
+	run("Excluded airlines", func(t *testing.T) {
+		actual := mapping.FilterFlightOffers(pkg.QueryParams{
+			MaxStops:         pkg.MaxStopsAny,
+			ExcludedAirlines: []string{"TG"},
+		}, googleflightsList...)
+
+		assert.Len(t, actual, 6)
+	})
+
+	run("Departure time window", func(t *testing.T) {
+		actual := mapping.FilterFlightOffers(pkg.QueryParams{
+			MaxStops:          pkg.MaxStopsAny,
+			DepartureTimeFrom: "20:00",
+			DepartureTimeTo:   "23:00",
+		}, googleflightsList...)
+
+		assert.Len(t, actual, 4)
+	})
+
+	run("Departure time window wrapping around midnight", func(t *testing.T) {
+		actual := mapping.FilterFlightOffers(pkg.QueryParams{
+			MaxStops:          pkg.MaxStopsAny,
+			DepartureTimeFrom: "22:00",
+			DepartureTimeTo:   "10:00",
+		}, googleflightsList...)
+
+		assert.Len(t, actual, 3)
+	})
+
+	run("Arrival time window", func(t *testing.T) {
+		actual := mapping.FilterFlightOffers(pkg.QueryParams{
+			MaxStops:      pkg.MaxStopsAny,
+			ArrivalTimeTo: "12:00",
+		}, googleflightsList...)
+
+		assert.Len(t, actual, 2)
+	})
+
+	run("Max price and duration", func(t *testing.T) {
+		actual := mapping.FilterFlightOffers(pkg.QueryParams{
+			MaxStops:    pkg.MaxStopsAny,
+			MaxPrice:    400,
+			MaxDuration: 600,
+		}, googleflightsList...)
+
+		assert.Len(t, actual, 2)
+		for _, offer := range actual {
+			assert.LessOrEqual(t, offer.Price.Value, 400.0)
+			assert.LessOrEqual(t, offer.DurationInMinutes, 600.0)
+		}
+	})
+}
+
+func TestNewFilterStats(t *testing.T) {
+	var googleflightsFlights googleflights.APIResponse
+	testhelpers.FileToStruct(t, filepath.Join("testdata", "googleflights-offers.json"), &googleflightsFlights)
+
+	googleflightsList := mapping.GoogleflightsToPkgFlights(make(chan error), googleflightsFlights.FlightOffer)
+	actual := mapping.NewFilterStats(googleflightsList...)
+
+	run := testhelpers.Run(t)
+
+	run("Price range", func(t *testing.T) {
+		assert.Equal(t, pkg.PriceRange{
+			Min: pkg.Amount{Value: 265, Currency: "USD"},
+			Max: pkg.Amount{Value: 770, Currency: "USD"},
+		}, actual.Price)
+	})
+
+	run("Duration range", func(t *testing.T) {
+		assert.Equal(t, pkg.DurationRange{Min: 560, Max: 1455}, actual.Duration)
+	})
+
+	run("Airlines sorted by lowest price", func(t *testing.T) {
+		assert.Len(t, actual.Airlines, 7)
+		assert.Equal(t, "TR", actual.Airlines[0].Code)
+		assert.Equal(t, 1, actual.Airlines[0].Offers)
+		assert.Equal(t, "TG", actual.Airlines[2].Code)
+		assert.Equal(t, 2, actual.Airlines[2].Offers)
+	})
+
+	run("Merged stats", func(t *testing.T) {
+		merged := mapping.MergeFilterStats(actual, actual, pkg.FilterStats{})
+		assert.Equal(t, actual.Price, merged.Price)
+		assert.Equal(t, actual.Duration, merged.Duration)
+		assert.Len(t, merged.Airlines, 7)
+		assert.Equal(t, 4, merged.Airlines[2].Offers)
+	})
+}
@@ -0,0 +1,91 @@
+package mapping
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// NewVendorPricedFlightOffers records the vendor quoting each of the flight offers, so they can be merged later on
+func NewVendorPricedFlightOffers(source string, flights ...pkg.FlightOffer) []pkg.FlightOffer {
+	results := make([]pkg.FlightOffer, 0, len(flights))
+	for _, flight := range flights {
+		flight.Source = source
+		flight.Prices = []pkg.VendorPrice{{Source: source, Price: flight.Price}}
+		results = append(results, flight)
+	}
+
+	return results
+}
+
+// MergeFlightOffers collapses offers for the very same flights into a single one, e.g when several vendors sell the same ticket
+// The cheapest offer is kept as the headline along with its source, and with the best price each vendor quoted for it
+func MergeFlightOffers(flights ...pkg.FlightOffer) []pkg.FlightOffer {
+	results := []pkg.FlightOffer{}
+	indexes := map[string]int{}
+	for _, flight := range flights {
+		key := flightOfferKey(flight)
+		i, ok := indexes[key]
+		if !ok {
+			indexes[key] = len(results)
+			results = append(results, flight)
+			continue
+		}
+
+		prices := mergeVendorPrices(results[i].Prices, flight.Prices)
+		if flight.Price.Value < results[i].Price.Value {
+			results[i] = flight
+		}
+		results[i].Prices = prices
+	}
+
+	return results
+}
+
+// flightOfferKey identifies the flights of an offer by their carrier, flight number and schedule on every bound
+func flightOfferKey(flight pkg.FlightOffer) string {
+	bounds := []string{}
+	for _, bound := range flight.Bounds() {
+		segments := []string{}
+		for _, segment := range bound.Segments {
+			segments = append(segments, fmt.Sprintf("%s%s@%s-%s",
+				segment.MarketingCarrier.Code,
+				segment.FlightNumber,
+				segment.Departure.Timestamp.Format(ISO8601TimeFormat),
+				segment.Arrival.Timestamp.Format(ISO8601TimeFormat),
+			))
+		}
+		bounds = append(bounds, strings.Join(segments, ","))
+	}
+
+	return strings.Join(bounds, "|")
+}
+
+// mergeVendorPrices keeps the cheapest price per vendor, sorted from cheapest to most expensive
+func mergeVendorPrices(a, b []pkg.VendorPrice) []pkg.VendorPrice {
+	lowest := map[string]pkg.VendorPrice{}
+	sources := []string{}
+	for _, price := range append(append([]pkg.VendorPrice{}, a...), b...) {
+		current, ok := lowest[price.Source]
+		if !ok {
+			sources = append(sources, price.Source)
+		}
+
+		if !ok || price.Price.Value < current.Price.Value {
+			lowest[price.Source] = price
+		}
+	}
+
+	results := make([]pkg.VendorPrice, 0, len(sources))
+	for _, source := range sources {
+		results = append(results, lowest[source])
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Price.Value < results[j].Price.Value
+	})
+
+	return results
+}
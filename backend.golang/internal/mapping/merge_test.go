@@ -0,0 +1,79 @@
+package mapping_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/amadeus"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/flightsky"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeFlightOffers(t *testing.T) {
+	var amadeusFlights amadeus.APIResponse
+	testhelpers.FileToStruct(t, filepath.Join("testdata", "amadeus-offers.json"), &amadeusFlights)
+
+	var amadeusOffers []amadeus.FlightOffer
+	if err := json.Unmarshal(amadeusFlights.Data, &amadeusOffers); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	var flightskyResp flightsky.APIResponse
+	testhelpers.FileToStruct(t, filepath.Join("testdata", "flightsky-offers.json"), &flightskyResp)
+
+	var flightskyOffers flightsky.FlightOffer
+	if err := json.Unmarshal(flightskyResp.Data, &flightskyOffers); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	amadeusList := mapping.NewVendorPricedFlightOffers(pkg.SourceAmadeus, mapping.AmadeusToPkgFlights(make(chan error), amadeusOffers, nil)...)
+	flightskyList := mapping.NewVendorPricedFlightOffers(pkg.SourceFlightsky, mapping.FlightskyToPkgFlights(make(chan error), flightskyOffers)...)
+
+	// the same flights sold by google, first one cheaper than amadeus and the rest more expensive
+	googleList := []pkg.FlightOffer{}
+	for i, offer := range amadeusList {
+		offer.Price.Value += 10
+		if i == 0 {
+			offer.Price.Value -= 20
+		}
+		googleList = append(googleList, offer)
+	}
+	googleList = mapping.NewVendorPricedFlightOffers(pkg.SourceGoogleflights, googleList...)
+
+	flights := append(append(append([]pkg.FlightOffer{}, amadeusList...), flightskyList...), googleList...)
+	actual := mapping.MergeFlightOffers(flights...)
+
+	run := testhelpers.Run(t)
+
+	run("Duplicated flights are merged", func(t *testing.T) {
+		assert.Len(t, actual, len(amadeusList)+len(flightskyList))
+	})
+
+	run("Cheapest vendor price is the headline", func(t *testing.T) {
+		assert.Equal(t, amadeusList[0].Price.Value-10, actual[0].Price.Value)
+		assert.Equal(t, pkg.SourceGoogleflights, actual[0].Source)
+		assert.Equal(t, []pkg.VendorPrice{
+			{Source: pkg.SourceGoogleflights, Price: pkg.Amount{Value: amadeusList[0].Price.Value - 10, Currency: "USD"}},
+			{Source: pkg.SourceAmadeus, Price: amadeusList[0].Price},
+		}, actual[0].Prices)
+
+		assert.Equal(t, amadeusList[1].Price, actual[1].Price)
+		assert.Equal(t, pkg.SourceAmadeus, actual[1].Source)
+		assert.Equal(t, []pkg.VendorPrice{
+			{Source: pkg.SourceAmadeus, Price: amadeusList[1].Price},
+			{Source: pkg.SourceGoogleflights, Price: pkg.Amount{Value: amadeusList[1].Price.Value + 10, Currency: "USD"}},
+		}, actual[1].Prices)
+	})
+
+	run("Flights sold by a single vendor are kept as they are", func(t *testing.T) {
+		for i, offer := range flightskyList {
+			assert.Equal(t, offer, actual[len(amadeusList)+i])
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package mapping
+
+import "github.com/rubengp99/golang-flights-challenge/pkg"
+
+// NewBestFlightsOffersPage slices every list of offers of a response into the same page, a limit of 0 means no limit
+// Pareto-optimal offers are a few of the rest, so they are returned whole instead of paged along with them
+func NewBestFlightsOffersPage(response pkg.GetBestFlightOffersResponse, offset, limit int) pkg.GetBestFlightOffersResponse {
+	total := len(response.Cheapest)
+	response.Cheapest = pageOf(response.Cheapest, offset, limit)
+	response.Fastest = pageOf(response.Fastest, offset, limit)
+	response.Best = pageOf(response.Best, offset, limit)
+	response.Sorted = pageOf(response.Sorted, offset, limit)
+	response.Pagination = &pkg.Pagination{
+		Limit:  limit,
+		Offset: offset,
+		Total:  total,
+	}
+
+	return response
+}
+
+func pageOf(flights []pkg.FlightOffer, offset, limit int) []pkg.FlightOffer {
+	if flights == nil {
+		return nil
+	}
+
+	if offset >= len(flights) {
+		return []pkg.FlightOffer{}
+	}
+
+	end := len(flights)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+
+	return flights[offset:end]
+}
@@ -0,0 +1,57 @@
+package mapping_test
+
+import (
+	"testing"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBestFlightsOffersPage(t *testing.T) {
+	flights := []pkg.FlightOffer{}
+	for i := 1; i <= 5; i++ {
+		flights = append(flights, pkg.FlightOffer{Price: pkg.Amount{Value: float64(i * 100), Currency: "USD"}})
+	}
+
+	response := pkg.GetBestFlightOffersResponse{
+		Cheapest: flights,
+		Fastest:  flights,
+		Pareto:   flights[:3],
+	}
+
+	run := testhelpers.Run(t)
+
+	run("First page", func(t *testing.T) {
+		page := mapping.NewBestFlightsOffersPage(response, 0, 2)
+		assert.Equal(t, flights[:2], page.Cheapest)
+		assert.Equal(t, flights[:2], page.Fastest)
+		assert.Nil(t, page.Best)
+		assert.Equal(t, &pkg.Pagination{Limit: 2, Offset: 0, Total: 5}, page.Pagination)
+	})
+
+	run("Pareto-optimal offers are not paged", func(t *testing.T) {
+		page := mapping.NewBestFlightsOffersPage(response, 2, 2)
+		assert.Equal(t, flights[:3], page.Pareto)
+		assert.Equal(t, 5, page.Pagination.Total)
+	})
+
+	run("Last page is shorter", func(t *testing.T) {
+		page := mapping.NewBestFlightsOffersPage(response, 4, 2)
+		assert.Equal(t, flights[4:], page.Cheapest)
+		assert.Equal(t, 5, page.Pagination.Total)
+	})
+
+	run("Offset out of range", func(t *testing.T) {
+		page := mapping.NewBestFlightsOffersPage(response, 10, 2)
+		assert.Empty(t, page.Cheapest)
+		assert.NotNil(t, page.Cheapest)
+	})
+
+	run("Response is not modified", func(t *testing.T) {
+		mapping.NewBestFlightsOffersPage(response, 2, 2)
+		assert.Len(t, response.Cheapest, 5)
+		assert.Nil(t, response.Pagination)
+	})
+}
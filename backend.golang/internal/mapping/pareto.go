@@ -0,0 +1,52 @@
+package mapping
+
+import (
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// ParetoFlightOffers returns the non-dominated flight offers sorted cheapest first, that is every offer for which no other
+// offer is at least as good on price, duration and optionally stops while being strictly better on any of them
+func ParetoFlightOffers(dimensions string, flights ...pkg.FlightOffer) []pkg.FlightOffer {
+	results := []pkg.FlightOffer{}
+	for i, flight := range flights {
+		dominated := false
+		for j, other := range flights {
+			if i != j && dominates(dimensions, other, flight) {
+				dominated = true
+				break
+			}
+		}
+
+		if !dominated {
+			results = append(results, flight)
+		}
+	}
+
+	return SortFlightOffers(ThenBy(ByPrice, ByDuration), results...)
+}
+
+// dominates reports whether an offer is at least as good as another one on every dimension, and better on any of them
+func dominates(dimensions string, a, b pkg.FlightOffer) bool {
+	values := func(flight pkg.FlightOffer) []float64 {
+		results := []float64{flight.Price.Value, flight.TotalDurationInMinutes()}
+		if dimensions == pkg.ParetoPriceDurationStops {
+			results = append(results, float64(flight.TotalLayovers()))
+		}
+
+		return results
+	}
+
+	better := false
+	bValues := values(b)
+	for i, value := range values(a) {
+		if value > bValues[i] {
+			return false
+		}
+
+		if value < bValues[i] {
+			better = true
+		}
+	}
+
+	return better
+}
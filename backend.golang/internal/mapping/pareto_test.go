@@ -0,0 +1,39 @@
+package mapping_test
+
+import (
+	"testing"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParetoFlightOffers(t *testing.T) {
+	cheap := newRankingFlightOffer("cheap", 300, 900, 2, 6)
+	fast := newRankingFlightOffer("fast", 600, 500, 0, 22)
+	balanced := newRankingFlightOffer("balanced", 350, 550, 0, 9)
+	// more expensive and slower than balanced
+	dominated := newRankingFlightOffer("dominated", 400, 600, 0, 10)
+	// as cheap as cheap while slower, yet with fewer stops
+	slowDirect := newRankingFlightOffer("slow-direct", 300, 950, 0, 12)
+
+	flights := []pkg.FlightOffer{fast, dominated, slowDirect, balanced, cheap}
+
+	run := testhelpers.Run(t)
+
+	run("Price and duration", func(t *testing.T) {
+		actual := mapping.ParetoFlightOffers(pkg.ParetoPriceDuration, flights...)
+		assert.Equal(t, []string{"cheap", "balanced", "fast"}, flightNumbers(actual))
+	})
+
+	run("Price, duration and stops", func(t *testing.T) {
+		actual := mapping.ParetoFlightOffers(pkg.ParetoPriceDurationStops, flights...)
+		assert.Equal(t, []string{"cheap", "slow-direct", "balanced", "fast"}, flightNumbers(actual))
+	})
+
+	run("Identical offers are both kept", func(t *testing.T) {
+		actual := mapping.ParetoFlightOffers(pkg.ParetoPriceDuration, cheap, cheap)
+		assert.Len(t, actual, 2)
+	})
+}
@@ -0,0 +1,156 @@
+package mapping
+
+import (
+	"math"
+	"sort"
+
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// Criteria used to rank flight offers
+const (
+	RankingCriteriaPrice         = "PRICE"
+	RankingCriteriaDuration      = "DURATION"
+	RankingCriteriaStops         = "STOPS"
+	RankingCriteriaDepartureTime = "DEPARTURE_TIME"
+	RankingCriteriaEmissions     = "EMISSIONS"
+)
+
+// DefaultRankingWeights are used by deployments not configuring their own
+var DefaultRankingWeights = pkg.RankingWeights{
+	Price:         0.5,
+	Duration:      0.25,
+	Stops:         0.15,
+	DepartureTime: 0.05,
+	Emissions:     0.05,
+}
+
+// departureTimeWindows maps departure time preferences to their hours, from the start hour up to the end one
+var departureTimeWindows = map[string][2]float64{
+	pkg.DepartureTimeNight:     {0, 5},
+	pkg.DepartureTimeMorning:   {5, 12},
+	pkg.DepartureTimeAfternoon: {12, 18},
+	pkg.DepartureTimeEvening:   {18, 24},
+}
+
+// rankingCriteria extracts the value to rank a flight offer on for a single criteria, lower values are better
+// Offers missing the data for a criteria, e.g vendors not reporting emissions, are ranked without it
+type rankingCriteria struct {
+	name   string
+	weight float64
+	value  func(flight pkg.FlightOffer) (float64, bool)
+}
+
+// RankFlightOffers sorts flight offers by a weighted score over price, duration, stops, departure time and emissions,
+// each of them scored relative to the rest of the offers, and attaches the ranking to every offer
+func RankFlightOffers(weights pkg.RankingWeights, departureTime string, flights ...pkg.FlightOffer) []pkg.FlightOffer {
+	criteria := []rankingCriteria{
+		{RankingCriteriaPrice, weights.Price, func(flight pkg.FlightOffer) (float64, bool) {
+			return flight.Price.Value, true
+		}},
+		{RankingCriteriaDuration, weights.Duration, func(flight pkg.FlightOffer) (float64, bool) {
+			return flight.TotalDurationInMinutes(), true
+		}},
+		{RankingCriteriaStops, weights.Stops, func(flight pkg.FlightOffer) (float64, bool) {
+			return float64(flight.TotalLayovers()), true
+		}},
+		{RankingCriteriaEmissions, weights.Emissions, func(flight pkg.FlightOffer) (float64, bool) {
+			emissions := flight.TotalEmissionsInGrams()
+			return emissions, emissions > 0
+		}},
+	}
+
+	// departure time is only ranked when travelers have a preference
+	if window, ok := departureTimeWindows[departureTime]; ok {
+		criteria = append(criteria, rankingCriteria{RankingCriteriaDepartureTime, weights.DepartureTime, func(flight pkg.FlightOffer) (float64, bool) {
+			return hoursOutsideWindow(flight.Departure.Timestamp.Hour(), flight.Departure.Timestamp.Minute(), window), true
+		}})
+	}
+
+	results := append([]pkg.FlightOffer(nil), flights...)
+	rankings := make([]pkg.Ranking, len(results))
+	totalWeights := make([]float64, len(results))
+	for _, c := range criteria {
+		if c.weight <= 0 {
+			continue
+		}
+
+		scores := relativeScores(c, results)
+		for i, score := range scores {
+			if score == nil {
+				continue
+			}
+
+			rankings[i].Score += *score * c.weight
+			rankings[i].Breakdown = append(rankings[i].Breakdown, pkg.RankingFactor{
+				Criteria: c.name,
+				Score:    math.Round(*score*100) / 100,
+				Weight:   c.weight,
+			})
+			totalWeights[i] += c.weight
+		}
+	}
+
+	for i := range results {
+		ranking := rankings[i]
+		if totalWeights[i] > 0 {
+			ranking.Score = math.Round(ranking.Score/totalWeights[i]*10000) / 100
+		}
+
+		if ranking.Breakdown == nil {
+			ranking.Breakdown = []pkg.RankingFactor{}
+		}
+
+		results[i].Ranking = &ranking
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Ranking.Score > results[j].Ranking.Score
+	})
+
+	return results
+}
+
+// relativeScores scores every offer from 0 (worst) to 1 (best) against the rest of the offers for a criteria,
+// offers missing the data for it are left without score
+func relativeScores(c rankingCriteria, flights []pkg.FlightOffer) []*float64 {
+	values := make([]*float64, len(flights))
+	lowest, highest := math.Inf(1), math.Inf(-1)
+	for i, flight := range flights {
+		value, ok := c.value(flight)
+		if !ok {
+			continue
+		}
+
+		values[i] = &value
+		lowest = math.Min(lowest, value)
+		highest = math.Max(highest, value)
+	}
+
+	scores := make([]*float64, len(flights))
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+
+		score := 1.0
+		if highest > lowest {
+			score = (highest - *value) / (highest - lowest)
+		}
+		scores[i] = &score
+	}
+
+	return scores
+}
+
+// hoursOutsideWindow returns how many hours a departure time is away from a time window, wrapping around midnight
+func hoursOutsideWindow(hour, minute int, window [2]float64) float64 {
+	at := float64(hour) + float64(minute)/60
+	if at >= window[0] && at < window[1] {
+		return 0
+	}
+
+	before := math.Mod(window[0]-at+24, 24)
+	after := math.Mod(at-window[1]+24, 24)
+	return math.Min(before, after)
+}
@@ -0,0 +1,82 @@
+package mapping_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRankingFlightOffer(flightNumber string, price, duration float64, layovers, departureHour int) pkg.FlightOffer {
+	return pkg.FlightOffer{
+		Itinerary: pkg.Itinerary{
+			FlightNumber:      flightNumber,
+			DurationInMinutes: duration,
+			Layovers:          layovers,
+			Departure: pkg.Location{
+				Timestamp: time.Date(2025, 5, 9, departureHour, 0, 0, 0, time.UTC),
+			},
+		},
+		Price: pkg.Amount{Value: price, Currency: "USD"},
+	}
+}
+
+func TestRankFlightOffers(t *testing.T) {
+	cheap := newRankingFlightOffer("cheap", 300, 900, 2, 6)
+	fast := newRankingFlightOffer("fast", 600, 500, 0, 22)
+	balanced := newRankingFlightOffer("balanced", 350, 550, 0, 9)
+
+	run := testhelpers.Run(t)
+
+	run("Price only ranking", func(t *testing.T) {
+		actual := mapping.RankFlightOffers(pkg.RankingWeights{Price: 1}, "", fast, balanced, cheap)
+		assert.Equal(t, []string{"cheap", "balanced", "fast"}, []string{actual[0].FlightNumber, actual[1].FlightNumber, actual[2].FlightNumber})
+		assert.Equal(t, 100.0, actual[0].Ranking.Score)
+		assert.Equal(t, 0.0, actual[2].Ranking.Score)
+	})
+
+	run("Weighted ranking", func(t *testing.T) {
+		actual := mapping.RankFlightOffers(pkg.RankingWeights{Price: 0.5, Duration: 0.3, Stops: 0.2}, "", fast, cheap, balanced)
+		assert.Equal(t, "balanced", actual[0].FlightNumber)
+		assert.Equal(t, &pkg.Ranking{
+			Score: 87.92,
+			Breakdown: []pkg.RankingFactor{
+				{Criteria: mapping.RankingCriteriaPrice, Score: 0.83, Weight: 0.5},
+				{Criteria: mapping.RankingCriteriaDuration, Score: 0.88, Weight: 0.3},
+				{Criteria: mapping.RankingCriteriaStops, Score: 1, Weight: 0.2},
+			},
+		}, actual[0].Ranking)
+	})
+
+	run("Departure time preference", func(t *testing.T) {
+		// 09:00 is 3 hours away from the afternoon, 22:00 is 4 hours away and 06:00 is 6 hours away
+		actual := mapping.RankFlightOffers(pkg.RankingWeights{DepartureTime: 1}, pkg.DepartureTimeAfternoon, cheap, fast, balanced)
+		assert.Equal(t, []string{"balanced", "fast", "cheap"}, []string{actual[0].FlightNumber, actual[1].FlightNumber, actual[2].FlightNumber})
+	})
+
+	run("Offers without emissions are ranked on the rest of criteria", func(t *testing.T) {
+		green := cheap
+		green.FlightNumber = "green"
+		green.EmissionsInGrams = 300000
+		polluting := cheap
+		polluting.FlightNumber = "polluting"
+		polluting.EmissionsInGrams = 600000
+
+		actual := mapping.RankFlightOffers(pkg.RankingWeights{Price: 0.5, Emissions: 0.5}, "", polluting, fast, green)
+		assert.Equal(t, "green", actual[0].FlightNumber)
+		assert.Equal(t, 100.0, actual[0].Ranking.Score)
+		assert.Equal(t, 50.0, actual[1].Ranking.Score)
+		assert.Equal(t, "fast", actual[2].FlightNumber)
+		assert.Len(t, actual[2].Ranking.Breakdown, 1)
+	})
+
+	run("Original offers are not modified", func(t *testing.T) {
+		flights := []pkg.FlightOffer{fast, cheap}
+		mapping.RankFlightOffers(mapping.DefaultRankingWeights, "", flights...)
+		assert.Nil(t, flights[0].Ranking)
+		assert.Equal(t, "fast", flights[0].FlightNumber)
+	})
+}
@@ -0,0 +1,110 @@
+package mapping
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// NewVendorReport reports a single vendor request made while serving a search
+func NewVendorReport(source string, offers int, latency time.Duration, err error) pkg.VendorReport {
+	report := pkg.VendorReport{
+		Source:      source,
+		Offers:      offers,
+		LatencyInMs: latency.Milliseconds(),
+		Cache:       pkg.CacheMiss,
+	}
+
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	return report
+}
+
+// NewTimedOutVendorReport reports a vendor request left behind as it took longer than the search allowed
+func NewTimedOutVendorReport(source string, latency time.Duration, err error) pkg.VendorReport {
+	report := NewVendorReport(source, 0, latency, err)
+	report.TimedOut = true
+	return report
+}
+
+// NewSkippedVendorReport reports a vendor request never sent on purpose, e.g as the vendor is down or out of quota
+func NewSkippedVendorReport(source string, offers int, latency time.Duration, reason error) pkg.VendorReport {
+	report := NewVendorReport(source, offers, latency, nil)
+	report.Skipped = reason.Error()
+	return report
+}
+
+// NewCachedVendorReports reports the vendors of a search served from cache, as none of them were requested again
+func NewCachedVendorReports(reports ...pkg.VendorReport) []pkg.VendorReport {
+	results := make([]pkg.VendorReport, 0, len(reports))
+	for _, report := range reports {
+		report.LatencyInMs = 0
+		report.Cache = pkg.CacheHit
+		results = append(results, report)
+	}
+
+	return results
+}
+
+// MergeVendorReports collapses every request made to the same vendor into a single report sorted by vendor,
+// vendor requests run concurrently so the slowest of them is the latency of the vendor
+func MergeVendorReports(reports ...pkg.VendorReport) []pkg.VendorReport {
+	merged := map[string]pkg.VendorReport{}
+	for _, report := range reports {
+		current, ok := merged[report.Source]
+		if !ok {
+			merged[report.Source] = report
+			continue
+		}
+
+		current.Offers += report.Offers
+		current.TimedOut = current.TimedOut || report.TimedOut
+		current.LatencyInMs = max(current.LatencyInMs, report.LatencyInMs)
+		// a single request hitting the vendor is enough to consider it a miss
+		if report.Cache == pkg.CacheMiss {
+			current.Cache = pkg.CacheMiss
+		}
+
+		if report.Error != "" && !strings.Contains(current.Error, report.Error) {
+			current.Error = strings.TrimPrefix(current.Error+"; "+report.Error, "; ")
+		}
+
+		if report.Skipped != "" && !strings.Contains(current.Skipped, report.Skipped) {
+			current.Skipped = strings.TrimPrefix(current.Skipped+"; "+report.Skipped, "; ")
+		}
+
+		merged[report.Source] = current
+	}
+
+	results := make([]pkg.VendorReport, 0, len(merged))
+	for _, report := range merged {
+		results = append(results, report)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Source < results[j].Source
+	})
+
+	return results
+}
+
+// NewVendorWarnings warns about every vendor failing or skipped on a search, as its offers are missing from the results
+func NewVendorWarnings(reports ...pkg.VendorReport) []string {
+	warnings := []string{}
+	for _, report := range reports {
+		if report.Error != "" {
+			warnings = append(warnings, fmt.Sprintf("%s failed, its offers are not included: %s", report.Source, report.Error))
+		}
+
+		if report.Skipped != "" {
+			warnings = append(warnings, fmt.Sprintf("%s skipped, its offers are not included: %s", report.Source, report.Skipped))
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,49 @@
+package mapping_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeVendorReports(t *testing.T) {
+	reports := []pkg.VendorReport{
+		mapping.NewVendorReport(pkg.SourceFlightsky, 3, 200*time.Millisecond, nil),
+		mapping.NewVendorReport(pkg.SourceAmadeus, 10, 450*time.Millisecond, nil),
+		mapping.NewVendorReport(pkg.SourceFlightsky, 0, 900*time.Millisecond, errors.New("flightsky - unexpected status 502")),
+		mapping.NewVendorReport(pkg.SourceAmadeus, 5, 300*time.Millisecond, nil),
+	}
+
+	run := testhelpers.Run(t)
+
+	run("Requests are merged per vendor", func(t *testing.T) {
+		actual := mapping.MergeVendorReports(reports...)
+		assert.Equal(t, []pkg.VendorReport{
+			{Source: pkg.SourceAmadeus, Offers: 15, LatencyInMs: 450, Cache: pkg.CacheMiss},
+			{Source: pkg.SourceFlightsky, Offers: 3, LatencyInMs: 900, Cache: pkg.CacheMiss, Error: "flightsky - unexpected status 502"},
+		}, actual)
+	})
+
+	run("Cached reports are hits", func(t *testing.T) {
+		actual := mapping.NewCachedVendorReports(mapping.MergeVendorReports(reports...)...)
+		assert.Equal(t, pkg.CacheHit, actual[0].Cache)
+		assert.Equal(t, int64(0), actual[0].LatencyInMs)
+		assert.Equal(t, 15, actual[0].Offers)
+	})
+
+	run("A single miss makes the vendor a miss", func(t *testing.T) {
+		cached := mapping.NewCachedVendorReports(reports[1])
+		actual := mapping.MergeVendorReports(append(cached, reports[3])...)
+		assert.Equal(t, pkg.CacheMiss, actual[0].Cache)
+	})
+
+	run("Failed vendors are warned about", func(t *testing.T) {
+		actual := mapping.NewVendorWarnings(mapping.MergeVendorReports(reports...)...)
+		assert.Equal(t, []string{"flightsky failed, its offers are not included: flightsky - unexpected status 502"}, actual)
+	})
+}
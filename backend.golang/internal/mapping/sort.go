@@ -0,0 +1,111 @@
+package mapping
+
+import (
+	"sort"
+
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// FlightOfferComparator reports whether a flight offer should be sorted before another one
+type FlightOfferComparator func(a, b pkg.FlightOffer) bool
+
+// ByPrice sorts the cheapest offers first
+func ByPrice(a, b pkg.FlightOffer) bool {
+	return a.Price.Value < b.Price.Value
+}
+
+// ByDuration sorts the shortest offers first, considering every bound
+func ByDuration(a, b pkg.FlightOffer) bool {
+	return a.TotalDurationInMinutes() < b.TotalDurationInMinutes()
+}
+
+// ByDeparture sorts the offers departing first on the outbound flight first
+func ByDeparture(a, b pkg.FlightOffer) bool {
+	return a.Departure.Timestamp.Before(b.Departure.Timestamp)
+}
+
+// ByArrival sorts the offers arriving first on the outbound flight first
+func ByArrival(a, b pkg.FlightOffer) bool {
+	return a.Arrival.Timestamp.Before(b.Arrival.Timestamp)
+}
+
+// ByStops sorts the offers with fewer stops first, considering every bound
+func ByStops(a, b pkg.FlightOffer) bool {
+	return a.TotalLayovers() < b.TotalLayovers()
+}
+
+// ByEmissions sorts the offers with the lowest carbon emissions first
+// Offers without emissions reported by their vendor are sorted last
+func ByEmissions(a, b pkg.FlightOffer) bool {
+	return lessReported(a.TotalEmissionsInGrams(), b.TotalEmissionsInGrams())
+}
+
+// ByPricePerHour sorts the offers with the lowest price per hour of flying time first
+// Offers without a duration reported by their vendor are sorted last
+func ByPricePerHour(a, b pkg.FlightOffer) bool {
+	return lessReported(pricePerHour(a), pricePerHour(b))
+}
+
+// Reverse inverts the order of a comparator
+func Reverse(less FlightOfferComparator) FlightOfferComparator {
+	return func(a, b pkg.FlightOffer) bool {
+		return less(b, a)
+	}
+}
+
+// ThenBy breaks the ties of a comparator with the following ones, in order
+func ThenBy(less FlightOfferComparator, tiebreakers ...FlightOfferComparator) FlightOfferComparator {
+	return func(a, b pkg.FlightOffer) bool {
+		for _, compare := range append([]FlightOfferComparator{less}, tiebreakers...) {
+			if compare(a, b) {
+				return true
+			}
+
+			if compare(b, a) {
+				return false
+			}
+		}
+
+		return false
+	}
+}
+
+// SortOrders maps every sort order available to clients to its comparator, ties are sorted cheapest first
+var SortOrders = map[string]FlightOfferComparator{
+	pkg.SortCheapest:          ByPrice,
+	pkg.SortFastest:           ThenBy(ByDuration, ByPrice),
+	pkg.SortEarliestDeparture: ThenBy(ByDeparture, ByPrice),
+	pkg.SortLatestDeparture:   ThenBy(Reverse(ByDeparture), ByPrice),
+	pkg.SortEarliestArrival:   ThenBy(ByArrival, ByPrice),
+	pkg.SortFewestStops:       ThenBy(ByStops, ByPrice),
+	pkg.SortLowestEmissions:   ThenBy(ByEmissions, ByPrice),
+	pkg.SortPricePerHour:      ThenBy(ByPricePerHour, ByPrice),
+}
+
+// SortFlightOffers returns a sorted copy of the flight offers, offers comparing equal keep their order
+func SortFlightOffers(less FlightOfferComparator, flights ...pkg.FlightOffer) []pkg.FlightOffer {
+	results := append([]pkg.FlightOffer(nil), flights...)
+	sort.SliceStable(results, func(i, j int) bool {
+		return less(results[i], results[j])
+	})
+
+	return results
+}
+
+// lessReported compares values where zero means the vendor did not report it, so those go last
+func lessReported(a, b float64) bool {
+	if a == 0 || b == 0 {
+		return a != 0 && b == 0
+	}
+
+	return a < b
+}
+
+func pricePerHour(flight pkg.FlightOffer) float64 {
+	duration := flight.TotalDurationInMinutes()
+	if duration <= 0 {
+		return 0
+	}
+
+	return flight.Price.Value / (duration / 60)
+}
@@ -0,0 +1,67 @@
+package mapping_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func flightNumbers(flights []pkg.FlightOffer) []string {
+	results := []string{}
+	for _, flight := range flights {
+		results = append(results, flight.FlightNumber)
+	}
+
+	return results
+}
+
+func TestSortFlightOffers(t *testing.T) {
+	// cheap departs at 06:00 arriving at 21:00, fast at 22:00 arriving at 06:20 and balanced at 09:00 arriving at 18:10
+	cheap := newRankingFlightOffer("cheap", 300, 900, 2, 6)
+	cheap.Arrival.Timestamp = time.Date(2025, 5, 9, 21, 0, 0, 0, time.UTC)
+	cheap.EmissionsInGrams = 250000
+
+	fast := newRankingFlightOffer("fast", 600, 500, 0, 22)
+	fast.Arrival.Timestamp = time.Date(2025, 5, 10, 6, 20, 0, 0, time.UTC)
+
+	balanced := newRankingFlightOffer("balanced", 350, 550, 0, 9)
+	balanced.Arrival.Timestamp = time.Date(2025, 5, 9, 18, 10, 0, 0, time.UTC)
+	balanced.EmissionsInGrams = 300000
+
+	flights := []pkg.FlightOffer{fast, balanced, cheap}
+
+	tests := []struct {
+		sort     string
+		expected []string
+	}{
+		{pkg.SortCheapest, []string{"cheap", "balanced", "fast"}},
+		{pkg.SortFastest, []string{"fast", "balanced", "cheap"}},
+		{pkg.SortEarliestDeparture, []string{"cheap", "balanced", "fast"}},
+		{pkg.SortLatestDeparture, []string{"fast", "balanced", "cheap"}},
+		{pkg.SortEarliestArrival, []string{"balanced", "cheap", "fast"}},
+		// balanced and fast are both direct, so the cheapest goes first
+		{pkg.SortFewestStops, []string{"balanced", "fast", "cheap"}},
+		// fast has no emissions reported
+		{pkg.SortLowestEmissions, []string{"cheap", "balanced", "fast"}},
+		// 20/h for cheap, ~38.18/h for balanced and 72/h for fast
+		{pkg.SortPricePerHour, []string{"cheap", "balanced", "fast"}},
+	}
+
+	run := testhelpers.Run(t)
+
+	for _, test := range tests {
+		run(test.sort, func(t *testing.T) {
+			actual := mapping.SortFlightOffers(mapping.SortOrders[test.sort], flights...)
+			assert.Equal(t, test.expected, flightNumbers(actual))
+		})
+	}
+
+	run("Flights are not modified", func(t *testing.T) {
+		mapping.SortFlightOffers(mapping.ByPrice, flights...)
+		assert.Equal(t, []string{"fast", "balanced", "cheap"}, flightNumbers(flights))
+	})
+}
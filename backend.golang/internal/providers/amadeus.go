@@ -0,0 +1,55 @@
+package providers
+
+import (
+	"context"
+	"time"
+
+	infisical "github.com/infisical/go-sdk"
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/amadeus"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// Amadeus searches flight offers and flight dates through amadeus
+type Amadeus struct {
+	service *amadeus.Service
+}
+
+// ProvideAmadeus builds the amadeus provider from its config
+func ProvideAmadeus(config amadeus.ConfigProviderFunc) ProvideFunc {
+	return func(client infisical.InfisicalClientInterface, projectID string) FlightProvider {
+		return NewAmadeus(amadeus.NewService(config, client, projectID))
+	}
+}
+
+// NewAmadeus returns a provider for an amadeus service
+func NewAmadeus(service amadeus.Service) Amadeus {
+	return Amadeus{service: &service}
+}
+
+func (p Amadeus) Source() string {
+	return pkg.SourceAmadeus
+}
+
+// Capabilities of amadeus, which is the only vendor able to price multi-city itineraries
+func (p Amadeus) Capabilities() Capabilities {
+	return Capabilities{MultiCity: true}
+}
+
+func (p Amadeus) RetrieveFlightOffers(ctx context.Context, params pkg.QueryParams) ([]pkg.FlightOffer, error) {
+	mappingErrors := make(chan error, 1)
+	flights, airlines, err := p.service.RetrieveFlightOffers(ctx, params)
+	offers := mapping.AmadeusToPkgFlights(mappingErrors, flights, airlines)
+	return offers, mappingError(err, mappingErrors)
+}
+
+func (p Amadeus) RetrieveFlightDates(ctx context.Context, origin, destination string, from, to time.Time) ([]pkg.CalendarDay, error) {
+	dates, currency, err := p.service.RetrieveFlightDates(ctx, origin, destination, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	mappingErrors := make(chan error, 1)
+	days := mapping.AmadeusToPkgCalendarDays(mappingErrors, dates, currency, time.Now().UTC())
+	return days, mappingError(nil, mappingErrors)
+}
@@ -0,0 +1,42 @@
+package providers
+
+import (
+	"context"
+
+	infisical "github.com/infisical/go-sdk"
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/flightsky"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// Flightsky searches flight offers through flights sky
+type Flightsky struct {
+	service *flightsky.Service
+}
+
+// ProvideFlightsky builds the flights sky provider from its config
+func ProvideFlightsky(config flightsky.ConfigProviderFunc) ProvideFunc {
+	return func(client infisical.InfisicalClientInterface, projectID string) FlightProvider {
+		return NewFlightsky(flightsky.NewService(config, client, projectID))
+	}
+}
+
+// NewFlightsky returns a provider for a flights sky service
+func NewFlightsky(service flightsky.Service) Flightsky {
+	return Flightsky{service: &service}
+}
+
+func (p Flightsky) Source() string {
+	return pkg.SourceFlightsky
+}
+
+func (p Flightsky) Capabilities() Capabilities {
+	return Capabilities{}
+}
+
+func (p Flightsky) RetrieveFlightOffers(ctx context.Context, params pkg.QueryParams) ([]pkg.FlightOffer, error) {
+	mappingErrors := make(chan error, 1)
+	flights, err := p.service.RetrieveFlightOffers(ctx, params)
+	offers := mapping.FlightskyToPkgFlights(mappingErrors, flights)
+	return offers, mappingError(err, mappingErrors)
+}
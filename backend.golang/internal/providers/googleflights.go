@@ -0,0 +1,50 @@
+package providers
+
+import (
+	"context"
+
+	infisical "github.com/infisical/go-sdk"
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/googleflights"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// Googleflights searches flight offers through google flights
+type Googleflights struct {
+	service *googleflights.Service
+}
+
+// ProvideGoogleflights builds the google flights provider from its config
+func ProvideGoogleflights(config googleflights.ConfigProviderFunc) ProvideFunc {
+	return func(client infisical.InfisicalClientInterface, projectID string) FlightProvider {
+		return NewGoogleflights(googleflights.NewService(config, client, projectID))
+	}
+}
+
+// NewGoogleflights returns a provider for a google flights service
+func NewGoogleflights(service googleflights.Service) Googleflights {
+	return Googleflights{service: &service}
+}
+
+func (p Googleflights) Source() string {
+	return pkg.SourceGoogleflights
+}
+
+// Capabilities of google flights, which searches several airports at once through comma separated codes
+func (p Googleflights) Capabilities() Capabilities {
+	return Capabilities{MultiAirport: true}
+}
+
+func (p Googleflights) RetrieveFlightOffers(ctx context.Context, params pkg.QueryParams) ([]pkg.FlightOffer, error) {
+	offers, _, _, err := p.RetrieveFlightOffersWithFare(ctx, params)
+	return offers, err
+}
+
+// RetrieveFlightOffersWithFare quotes the lowest fare from google price insights
+func (p Googleflights) RetrieveFlightOffersWithFare(ctx context.Context, params pkg.QueryParams) ([]pkg.FlightOffer, pkg.Amount, bool, error) {
+	mappingErrors := make(chan error, 1)
+	flights, err := p.service.RetrieveFlightOffers(ctx, params)
+	offers := mapping.GoogleflightsToPkgFlights(mappingErrors, flights)
+	fare, ok := mapping.GoogleflightsToPkgLowestFare(flights)
+	return offers, fare, ok, mappingError(err, mappingErrors)
+}
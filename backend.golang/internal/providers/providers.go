@@ -0,0 +1,90 @@
+package providers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	infisical "github.com/infisical/go-sdk"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// FlightProvider is a vendor able to search flight offers
+type FlightProvider interface {
+	// Source identifies the vendor, e.g pkg.SourceAmadeus
+	Source() string
+	Capabilities() Capabilities
+	RetrieveFlightOffers(ctx context.Context, params pkg.QueryParams) ([]pkg.FlightOffer, error)
+}
+
+// FareProvider is implemented by providers quoting the lowest fare of a search on their own, e.g google price insights
+// The lowest fare of the rest of providers is taken from the cheapest of their offers
+type FareProvider interface {
+	FlightProvider
+	// RetrieveFlightOffersWithFare reports false when the vendor has no fare to quote for the search
+	RetrieveFlightOffersWithFare(ctx context.Context, params pkg.QueryParams) ([]pkg.FlightOffer, pkg.Amount, bool, error)
+}
+
+// FlightDatesProvider is implemented by providers quoting the cheapest fare per departure date, used by the price calendar
+type FlightDatesProvider interface {
+	FlightProvider
+	RetrieveFlightDates(ctx context.Context, origin, destination string, from, to time.Time) ([]pkg.CalendarDay, error)
+}
+
+// Capabilities describes which searches a provider is able to handle
+type Capabilities struct {
+	MultiCity    bool // providers without it are skipped on multi-city searches
+	MultiAirport bool // providers with it search comma separated airport codes at once, instead of one search per airport pair
+}
+
+// ProvideFunc builds a provider, once the secrets manager is available
+type ProvideFunc func(client infisical.InfisicalClientInterface, projectID string) FlightProvider
+
+// Registry holds every provider searched, by source
+type Registry struct {
+	providers map[string]FlightProvider
+}
+
+// NewRegistry returns a registry holding the providers, a provider replaces any previous one with the same source
+func NewRegistry(providers ...FlightProvider) Registry {
+	r := Registry{providers: map[string]FlightProvider{}}
+	for _, provider := range providers {
+		r.providers[provider.Source()] = provider
+	}
+
+	return r
+}
+
+// Providers lists every provider sorted by source, so searches are always dispatched in the same order
+func (r Registry) Providers() []FlightProvider {
+	results := make([]FlightProvider, 0, len(r.providers))
+	for _, provider := range r.providers {
+		results = append(results, provider)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Source() < results[j].Source()
+	})
+
+	return results
+}
+
+// Provider returns the provider of a source, if registered
+func (r Registry) Provider(source string) (FlightProvider, bool) {
+	provider, ok := r.providers[source]
+	return provider, ok
+}
+
+// mappingError returns the error of a vendor request, or the one reported while mapping its response
+func mappingError(err error, mappingErrors chan error) error {
+	if err != nil {
+		return err
+	}
+
+	select {
+	case err := <-mappingErrors:
+		return err
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,67 @@
+package providers_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/providers"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubProvider struct {
+	source string
+	offers []pkg.FlightOffer
+}
+
+func (s stubProvider) Source() string {
+	return s.source
+}
+
+func (s stubProvider) Capabilities() providers.Capabilities {
+	return providers.Capabilities{}
+}
+
+func (s stubProvider) RetrieveFlightOffers(ctx context.Context, params pkg.QueryParams) ([]pkg.FlightOffer, error) {
+	return s.offers, nil
+}
+
+func sources(list []providers.FlightProvider) []string {
+	results := []string{}
+	for _, provider := range list {
+		results = append(results, provider.Source())
+	}
+
+	return results
+}
+
+func TestRegistry(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	run("Providers are sorted by source", func(t *testing.T) {
+		registry := providers.NewRegistry(
+			stubProvider{source: pkg.SourceGoogleflights},
+			stubProvider{source: pkg.SourceAmadeus},
+			stubProvider{source: pkg.SourceFlightsky},
+		)
+
+		assert.Equal(t, []string{pkg.SourceAmadeus, pkg.SourceFlightsky, pkg.SourceGoogleflights}, sources(registry.Providers()))
+	})
+
+	run("Later providers replace previous ones with the same source", func(t *testing.T) {
+		replacement := stubProvider{source: pkg.SourceAmadeus, offers: []pkg.FlightOffer{{Source: pkg.SourceAmadeus}}}
+		registry := providers.NewRegistry(stubProvider{source: pkg.SourceAmadeus}, replacement)
+
+		provider, ok := registry.Provider(pkg.SourceAmadeus)
+		assert.True(t, ok)
+		assert.Equal(t, replacement, provider)
+		assert.Len(t, registry.Providers(), 1)
+	})
+
+	run("Unknown sources are not found", func(t *testing.T) {
+		_, ok := providers.NewRegistry().Provider(pkg.SourceFlightsky)
+		assert.False(t, ok)
+		assert.Empty(t, providers.NewRegistry().Providers())
+	})
+}
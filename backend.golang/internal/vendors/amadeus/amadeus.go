@@ -138,3 +138,79 @@ type Airline struct {
 	IcaoCode     string `json:"icaoCode"`
 	BusinessName string `json:"businessName"`
 }
+
+// SearchRequest represents the body for a flight offers search in Amadeus API format
+type SearchRequest struct {
+	CurrencyCode       string              `json:"currencyCode"`
+	OriginDestinations []OriginDestination `json:"originDestinations"`
+	Travelers          []Traveler          `json:"travelers"`
+	Sources            []string            `json:"sources"`
+	SearchCriteria     SearchCriteria      `json:"searchCriteria"`
+}
+
+// OriginDestination represents a single leg to be searched within a flight offers search
+type OriginDestination struct {
+	ID                      string        `json:"id"`
+	OriginLocationCode      string        `json:"originLocationCode"`
+	DestinationLocationCode string        `json:"destinationLocationCode"`
+	DepartureDateTimeRange  DateTimeRange `json:"departureDateTimeRange"`
+}
+
+// DateTimeRange represents the departure date for a leg within a flight offers search
+type DateTimeRange struct {
+	Date string `json:"date"`
+}
+
+// Traveler types supported by amadeus
+const (
+	TravelerTypeAdult        = "ADULT"
+	TravelerTypeChild        = "CHILD"
+	TravelerTypeSenior       = "SENIOR"
+	TravelerTypeSeatedInfant = "SEATED_INFANT"
+	TravelerTypeHeldInfant   = "HELD_INFANT"
+)
+
+// Traveler represents a traveler to be priced within a flight offers search
+type Traveler struct {
+	ID                string `json:"id"`
+	TravelerType      string `json:"travelerType"`
+	AssociatedAdultID string `json:"associatedAdultId,omitempty"`
+}
+
+// SearchCriteria represents restrictions applied to a flight offers search
+type SearchCriteria struct {
+	FlightFilters FlightFilters `json:"flightFilters"`
+}
+
+// FlightFilters represents flight restrictions applied to a flight offers search
+type FlightFilters struct {
+	CabinRestrictions     []CabinRestriction    `json:"cabinRestrictions,omitempty"`
+	ConnectionRestriction ConnectionRestriction `json:"connectionRestriction"`
+}
+
+// CabinRestriction represents the cabin to be searched for a set of legs within a flight offers search
+type CabinRestriction struct {
+	Cabin                string   `json:"cabin"`
+	Coverage             string   `json:"coverage"`
+	OriginDestinationIDs []string `json:"originDestinationIds"`
+}
+
+// ConnectionRestriction represents restrictions over connecting flights within a flight offers search
+type ConnectionRestriction struct {
+	MaxNumberOfConnections int `json:"maxNumberOfConnections"`
+}
+
+// FlightDate represents the cheapest fare found by amadeus for a departure date
+type FlightDate struct {
+	Type          string          `json:"type"`
+	Origin        string          `json:"origin"`
+	Destination   string          `json:"destination"`
+	DepartureDate string          `json:"departureDate"`
+	ReturnDate    string          `json:"returnDate,omitempty"`
+	Price         FlightDatePrice `json:"price"`
+}
+
+// FlightDatePrice represents pricing information about a flight date, currency is shared across the response meta
+type FlightDatePrice struct {
+	Total string `json:"total"`
+}
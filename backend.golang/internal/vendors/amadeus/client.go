@@ -1,12 +1,14 @@
 package amadeus
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -29,8 +31,9 @@ type AuthResponse struct {
 }
 
 type Meta struct {
-	Count int       `json:"count"`
-	Links MetaLinks `json:"links"`
+	Count    int       `json:"count"`
+	Currency string    `json:"currency,omitempty"` // only present on flight dates
+	Links    MetaLinks `json:"links"`
 }
 
 type MetaLinks struct {
@@ -57,7 +60,7 @@ type ConfigProviderFunc func(client infisical.InfisicalClientInterface, projectI
 func DefaultConfigFromSecretsManager() ConfigProviderFunc {
 	return func(client infisical.InfisicalClientInterface, projectID string) vendors.Config {
 		var (
-			c      = vendors.Config{}
+			c      = vendors.Config{Retry: vendors.DefaultRetryPolicy()}
 			errors = make(chan error)
 			wgdone = make(chan bool)
 			wg     sync.WaitGroup
@@ -152,6 +155,7 @@ func (s *Service) Authenticate(req *http.Request) error {
 			BaseURL:     s.config.BaseURL,
 			Resource:    "v1/security/oauth2/token",
 			Method:      http.MethodPost,
+			Retryable:   true, // requesting a token has no side effects
 			Payload: url.Values{
 				"client_id":     []string{s.config.ClientID},
 				"client_secret": []string{s.config.ClientSecret},
@@ -165,7 +169,7 @@ func (s *Service) Authenticate(req *http.Request) error {
 		return nil
 	}
 
-	if err := vendors.MakeHTTPRequest(s, request, &response); err != nil {
+	if err := vendors.MakeHTTPRequest(req.Context(), s, request, &response); err != nil {
 		log.Printf("unable to authenticate with amadeus, error: %s", err)
 		return err
 	}
@@ -180,13 +184,17 @@ func (s Service) Client() *http.Client {
 	return s.httpclient
 }
 
+// RetryPolicy exports how failed requests to the current integration are retried
+func (s Service) RetryPolicy() vendors.RetryPolicy {
+	return s.config.Retry
+}
+
 // RetrieveFlightOffers retrives all available flight offers from amadeus
-func (s *Service) RetrieveFlightOffers(params pkg.QueryParams) ([]FlightOffer, []Airline, error) {
+func (s *Service) RetrieveFlightOffers(ctx context.Context, params pkg.QueryParams) ([]FlightOffer, []Airline, error) {
 	var (
 		response APIResponse
 		offers   = []FlightOffer{}
 		request  = vendors.Request{
-
 			BaseURL:  s.config.BaseURL,
 			Resource: "v2/shopping/flight-offers",
 			Method:   http.MethodGet,
@@ -194,14 +202,41 @@ func (s *Service) RetrieveFlightOffers(params pkg.QueryParams) ([]FlightOffer, [
 				"originLocationCode":      []string{params.Origin},
 				"destinationLocationCode": []string{params.Destination},
 				"departureDate":           []string{params.Date.Format("2006-01-02")},
-				"adults":                  []string{params.Adults},
-				"nonStop":                 []string{"true"}, // to keep things simple, only direct flights
-				"currencyCode":            []string{"USD"},  // amadeus uses EUR as default, so we need to specify this
+				"adults":                  []string{strconv.Itoa(params.Adults)},
+				"nonStop":                 []string{strconv.FormatBool(params.MaxStops == pkg.MaxStopsDirect)},
+				"currencyCode":            []string{params.CurrencyCode()}, // amadeus uses EUR as default, so we need to specify this
 			},
 		}
 	)
 
-	if err := vendors.MakeHTTPRequest(s, request, &response); err != nil {
+	if params.IsRoundTrip() {
+		request.Params.Set("returnDate", params.ReturnDate.Format("2006-01-02"))
+	}
+
+	if params.Children > 0 {
+		request.Params.Set("children", strconv.Itoa(params.Children))
+	}
+
+	if params.InfantsOnLap > 0 {
+		request.Params.Set("infants", strconv.Itoa(params.InfantsOnLap))
+	}
+
+	// amadeus cabins use the same naming as ours
+	if params.Cabin != "" {
+		request.Params.Set("travelClass", params.Cabin)
+	}
+
+	// multi-city searches, seniors and seated infants are only available through the POST version of the same API
+	if params.IsMultiCity() || params.Seniors > 0 || params.InfantsInSeat > 0 {
+		request.Method = http.MethodPost
+		request.ContentType = vendors.ContentTypeJSON
+		request.Headers = http.Header{"X-HTTP-Method-Override": []string{http.MethodGet}}
+		request.Params = url.Values{}
+		request.Payload = newSearchRequest(params)
+		request.Retryable = true // the POST is only an override of the search, so it is safe to retry
+	}
+
+	if err := vendors.MakeHTTPRequest(ctx, s, request, &response); err != nil {
 		log.Printf("unable to retrieve flights from amadeus, error: %s", err)
 		return nil, nil, err
 	}
@@ -223,7 +258,7 @@ func (s *Service) RetrieveFlightOffers(params pkg.QueryParams) ([]FlightOffer, [
 		}
 	}
 
-	airlines, err := s.retrieveAirlines(airlineCodes)
+	airlines, err := s.retrieveAirlines(ctx, airlineCodes)
 	if err != nil {
 		log.Printf("unable to retrieve airlines from amadeus, error: %s", err)
 		return nil, nil, err
@@ -232,7 +267,117 @@ func (s *Service) RetrieveFlightOffers(params pkg.QueryParams) ([]FlightOffer, [
 	return offers, airlines, nil
 }
 
-func (s *Service) retrieveAirlines(codes []string) ([]Airline, error) {
+// RetrieveFlightDates retrieves the cheapest one way fare per departure date between two dates from amadeus,
+// along with the currency all fares are priced in
+func (s *Service) RetrieveFlightDates(ctx context.Context, origin, destination string, from, to time.Time) ([]FlightDate, string, error) {
+	var (
+		response APIResponse
+		dates    = []FlightDate{}
+		request  = vendors.Request{
+			BaseURL:  s.config.BaseURL,
+			Resource: "v1/shopping/flight-dates",
+			Method:   http.MethodGet,
+			Params: url.Values{
+				"origin":        []string{origin},
+				"destination":   []string{destination},
+				"departureDate": []string{fmt.Sprintf("%s,%s", from.Format("2006-01-02"), to.Format("2006-01-02"))},
+				"oneWay":        []string{"true"},
+				"viewBy":        []string{"DATE"},
+			},
+		}
+	)
+
+	if err := vendors.MakeHTTPRequest(ctx, s, request, &response); err != nil {
+		log.Printf("unable to retrieve flight dates from amadeus, error: %s", err)
+		return nil, "", err
+	}
+
+	if err := json.Unmarshal(response.Data, &dates); err != nil {
+		log.Printf("unable to decode flight dates from amadeus, error: %s", err)
+		return nil, "", err
+	}
+
+	return dates, response.Meta.Currency, nil
+}
+
+func newSearchRequest(params pkg.QueryParams) SearchRequest {
+	request := SearchRequest{
+		CurrencyCode: params.CurrencyCode(), // amadeus uses EUR as default, so we need to specify this
+		Sources:      []string{"GDS"},
+		SearchCriteria: SearchCriteria{
+			FlightFilters: FlightFilters{
+				// amadeus allows up to 2 connections, which matches our maximum
+				ConnectionRestriction: ConnectionRestriction{MaxNumberOfConnections: params.MaxStops},
+			},
+		},
+	}
+
+	legs := params.Legs
+	if !params.IsMultiCity() {
+		legs = []pkg.Leg{{Origin: params.Origin, Destination: params.Destination, Date: params.Date}}
+		if params.IsRoundTrip() {
+			legs = append(legs, pkg.Leg{Origin: params.Destination, Destination: params.Origin, Date: params.ReturnDate})
+		}
+	}
+
+	ids := []string{}
+	for i, leg := range legs {
+		ids = append(ids, strconv.Itoa(i+1))
+		request.OriginDestinations = append(request.OriginDestinations, OriginDestination{
+			ID:                      ids[i],
+			OriginLocationCode:      leg.Origin,
+			DestinationLocationCode: leg.Destination,
+			DepartureDateTimeRange: DateTimeRange{
+				Date: leg.Date.Format("2006-01-02"),
+			},
+		})
+	}
+
+	if params.Cabin != "" {
+		request.SearchCriteria.FlightFilters.CabinRestrictions = []CabinRestriction{{
+			Cabin:                params.Cabin,
+			Coverage:             "MOST_SEGMENTS",
+			OriginDestinationIDs: ids,
+		}}
+	}
+
+	travelers := []struct {
+		travelerType string
+		count        int
+	}{
+		{TravelerTypeAdult, params.Adults},
+		{TravelerTypeSenior, params.Seniors},
+		{TravelerTypeChild, params.Children},
+		{TravelerTypeSeatedInfant, params.InfantsInSeat},
+		{TravelerTypeHeldInfant, params.InfantsOnLap},
+	}
+
+	// held infants travel on the lap of an adult, so each of them is associated to a different one
+	guardians := []string{}
+	for _, t := range travelers {
+		for i := 0; i < t.count; i++ {
+			traveler := Traveler{
+				ID:           strconv.Itoa(len(request.Travelers) + 1),
+				TravelerType: t.travelerType,
+			}
+
+			switch t.travelerType {
+			case TravelerTypeAdult, TravelerTypeSenior:
+				guardians = append(guardians, traveler.ID)
+			case TravelerTypeHeldInfant:
+				if len(guardians) > 0 {
+					traveler.AssociatedAdultID = guardians[i%len(guardians)]
+				}
+			}
+
+			request.Travelers = append(request.Travelers, traveler)
+		}
+	}
+
+	return request
+}
+
+func (s *Service) retrieveAirlines(ctx context.Context, codes []string) ([]Airline, error) {
 	var (
 		response APIResponse
 		airlines = []Airline{}
@@ -246,7 +391,7 @@ func (s *Service) retrieveAirlines(codes []string) ([]Airline, error) {
 		}
 	)
 
-	if err := vendors.MakeHTTPRequest(s, request, &response); err != nil {
+	if err := vendors.MakeHTTPRequest(ctx, s, request, &response); err != nil {
 		log.Printf("unable to retrieve flights from amadeus, error: %s", err)
 		return nil, err
 	}
@@ -106,11 +106,11 @@ func TestRetrieveFlightOffers(t *testing.T) {
 
 	date, _ := time.Parse("2006-01-02", "2025-05-09")
 
-	flights, airlines, err := service.RetrieveFlightOffers(pkg.QueryParams{
+	flights, airlines, err := service.RetrieveFlightOffers(context.Background(), pkg.QueryParams{
 		Origin:      "SYD",
 		Destination: "BKK",
 		Date:        date,
-		Adults:      "1",
+		Adults:      1,
 	})
 
 	run("No errors", func(t *testing.T) {
@@ -125,3 +125,188 @@ func TestRetrieveFlightOffers(t *testing.T) {
 		testhelpers.AssertJSONEquals(t, filepath.Join("testdata", "offers.json"), flights)
 	})
 }
+
+func TestRetrieveMultiCityFlightOffers(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.String() {
+		case "/v1/reference-data/airlines?airlineCodes=TG%2CQF":
+			var response APIResponse
+			reader := testhelpers.FileToStruct(t, filepath.Join("testdata", "amadeus-airlines.json"), &response)
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(data)
+		case "/v2/shopping/flight-offers?":
+			var request SearchRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			run("Method as expected", func(t *testing.T) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, http.MethodGet, r.Header.Get("X-HTTP-Method-Override"))
+			})
+
+			run("Payload is as expected", func(t *testing.T) {
+				assert.Len(t, request.OriginDestinations, 2)
+				assert.Equal(t, "SYD", request.OriginDestinations[0].OriginLocationCode)
+				assert.Equal(t, "BKK", request.OriginDestinations[0].DestinationLocationCode)
+				assert.Equal(t, "2025-05-09", request.OriginDestinations[0].DepartureDateTimeRange.Date)
+				assert.Equal(t, "BKK", request.OriginDestinations[1].OriginLocationCode)
+				assert.Equal(t, "SIN", request.OriginDestinations[1].DestinationLocationCode)
+				assert.Equal(t, "2025-05-12", request.OriginDestinations[1].DepartureDateTimeRange.Date)
+			})
+
+			run("Travelers are as expected", func(t *testing.T) {
+				assert.Equal(t, []Traveler{
+					{ID: "1", TravelerType: TravelerTypeAdult},
+					{ID: "2", TravelerType: TravelerTypeAdult},
+					{ID: "3", TravelerType: TravelerTypeSenior},
+					{ID: "4", TravelerType: TravelerTypeHeldInfant, AssociatedAdultID: "1"},
+				}, request.Travelers)
+			})
+
+			var response APIResponse
+			reader := testhelpers.FileToStruct(t, filepath.Join("testdata", "amadeus-offers.json"), &response)
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(data)
+		case "/v1/security/oauth2/token?":
+			response := AuthResponse{
+				TokenType:   "Bearer",
+				AccessToken: "TestAccessToken",
+			}
+			data, err := json.Marshal(response)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(data)
+		default:
+			t.Errorf("unexpected path %s", r.URL.String())
+			t.FailNow()
+		}
+
+	}))
+	defer testServer.Close()
+
+	mockConfigProvide := func(client infisical.InfisicalClientInterface, projectID string) vendors.Config {
+		return vendors.Config{
+			BaseURL:      testServer.URL,
+			ClientID:     "testClientId",
+			ClientSecret: "testClientSecret",
+		}
+	}
+
+	service := NewService(mockConfigProvide, infisical.NewInfisicalClient(context.Background(), infisical.Config{}), "")
+
+	firstDate, _ := time.Parse("2006-01-02", "2025-05-09")
+	secondDate, _ := time.Parse("2006-01-02", "2025-05-12")
+
+	flights, _, err := service.RetrieveFlightOffers(context.Background(), pkg.QueryParams{
+		Legs: []pkg.Leg{
+			{Origin: "SYD", Destination: "BKK", Date: firstDate},
+			{Origin: "BKK", Destination: "SIN", Date: secondDate},
+		},
+		Adults:       2,
+		Seniors:      1,
+		InfantsOnLap: 1,
+	})
+
+	run("No errors", func(t *testing.T) {
+		assert.NoError(t, err)
+	})
+
+	run("Flights as expected", func(t *testing.T) {
+		testhelpers.AssertJSONEquals(t, filepath.Join("testdata", "offers.json"), flights)
+	})
+}
+
+func TestRetrieveFlightDates(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.String() {
+		case "/v1/shopping/flight-dates?departureDate=2025-05-09%2C2025-05-31&destination=BKK&oneWay=true&origin=SYD&viewBy=DATE":
+			var response APIResponse
+			reader := testhelpers.FileToStruct(t, filepath.Join("testdata", "amadeus-flight-dates.json"), &response)
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			run("Method as expected", func(t *testing.T) {
+				assert.Equal(t, http.MethodGet, r.Method)
+			})
+
+			run("Token as expected", func(t *testing.T) {
+				assert.Equal(t, "Bearer TestAccessToken", r.Header.Get("Authorization"))
+			})
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(data)
+		case "/v1/security/oauth2/token?":
+			response := AuthResponse{
+				TokenType:   "Bearer",
+				AccessToken: "TestAccessToken",
+			}
+			data, err := json.Marshal(response)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(data)
+		default:
+			t.Errorf("unexpected path %s", r.URL.String())
+			t.FailNow()
+		}
+
+	}))
+	defer testServer.Close()
+
+	mockConfigProvide := func(client infisical.InfisicalClientInterface, projectID string) vendors.Config {
+		return vendors.Config{
+			BaseURL:      testServer.URL,
+			ClientID:     "testClientId",
+			ClientSecret: "testClientSecret",
+		}
+	}
+
+	service := NewService(mockConfigProvide, infisical.NewInfisicalClient(context.Background(), infisical.Config{}), "")
+
+	from, _ := time.Parse("2006-01-02", "2025-05-09")
+	to, _ := time.Parse("2006-01-02", "2025-05-31")
+
+	dates, currency, err := service.RetrieveFlightDates(context.Background(), "SYD", "BKK", from, to)
+
+	run("No errors", func(t *testing.T) {
+		assert.NoError(t, err)
+	})
+
+	run("Currency as expected", func(t *testing.T) {
+		assert.Equal(t, "USD", currency)
+	})
+
+	run("Flight dates as expected", func(t *testing.T) {
+		assert.Len(t, dates, 3)
+		assert.Equal(t, "2025-05-10", dates[1].DepartureDate)
+		assert.Equal(t, "389.10", dates[1].Price.Total)
+	})
+}
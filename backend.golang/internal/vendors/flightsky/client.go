@@ -1,11 +1,13 @@
 package flightsky
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -34,7 +36,7 @@ type ConfigProviderFunc func(infclient infisical.InfisicalClientInterface, proje
 func DefaultConfigFromSecretsManager() ConfigProviderFunc {
 	return func(infclient infisical.InfisicalClientInterface, projectID string) vendors.Config {
 		var (
-			c      = vendors.Config{}
+			c      = vendors.Config{Retry: vendors.DefaultRetryPolicy()}
 			errors = make(chan error)
 			wgdone = make(chan bool)
 			wg     sync.WaitGroup
@@ -119,8 +121,13 @@ func (s Service) Client() *http.Client {
 	return s.httpclient
 }
 
+// RetryPolicy exports how failed requests to the current integration are retried
+func (s Service) RetryPolicy() vendors.RetryPolicy {
+	return s.config.Retry
+}
+
 // RetrieveFlightOffers retrives all available flight offers from flights sky
-func (s *Service) RetrieveFlightOffers(params pkg.QueryParams) (FlightOffer, error) {
+func (s *Service) RetrieveFlightOffers(ctx context.Context, params pkg.QueryParams) (FlightOffer, error) {
 	var (
 		response APIResponse
 		offers   = FlightOffer{}
@@ -132,14 +139,41 @@ func (s *Service) RetrieveFlightOffers(params pkg.QueryParams) (FlightOffer, err
 				"fromEntityId": []string{params.Origin},
 				"toEntityId":   []string{params.Destination},
 				"departDate":   []string{params.Date.Format("2006-01-02")},
-				"adults":       []string{params.Adults},
-				"currencyCode": []string{"USD"},
-				"stops":        []string{"direct"}, // to keep things simple, only direct flights
+				// flights sky has no senior fares, so seniors are searched as adults
+				"adults":       []string{strconv.Itoa(params.Adults + params.Seniors)},
+				"currencyCode": []string{params.CurrencyCode()},
 			},
 		}
 	)
 
-	if err := vendors.MakeHTTPRequest(s, request, &response); err != nil {
+	// no stops param means any number of stops
+	switch params.MaxStops {
+	case pkg.MaxStopsDirect:
+		request.Params.Set("stops", "direct")
+	case pkg.MaxStopsOne:
+		request.Params.Set("stops", "direct,1stop")
+	}
+
+	if params.Children > 0 {
+		request.Params.Set("children", strconv.Itoa(params.Children))
+	}
+
+	// flights sky does not distinguish between infants on lap and infants in seat
+	if infants := params.InfantsOnLap + params.InfantsInSeat; infants > 0 {
+		request.Params.Set("infants", strconv.Itoa(infants))
+	}
+
+	// flights sky cabins use the same naming as ours, but lowercased
+	if params.Cabin != "" {
+		request.Params.Set("cabinClass", strings.ToLower(params.Cabin))
+	}
+
+	if params.IsRoundTrip() {
+		request.Resource = "flights/search-roundtrip"
+		request.Params.Set("returnDate", params.ReturnDate.Format("2006-01-02"))
+	}
+
+	if err := vendors.MakeHTTPRequest(ctx, s, request, &response); err != nil {
 		log.Printf("unable to retrieve flights from flights sky, error: %s", err)
 		return FlightOffer{}, err
 	}
@@ -149,5 +183,7 @@ func (s *Service) RetrieveFlightOffers(params pkg.QueryParams) (FlightOffer, err
 		return FlightOffer{}, err
 	}
 
+	// flights sky prices in the requested currency, but only reports it back as a formatted symbol
+	offers.Currency = params.CurrencyCode()
 	return offers, nil
 }
@@ -59,11 +59,11 @@ func TestRetrieveFlightOffers(t *testing.T) {
 
 	date, _ := time.Parse("2006-01-02", "2025-05-09")
 
-	flights, err := service.RetrieveFlightOffers(pkg.QueryParams{
+	flights, err := service.RetrieveFlightOffers(context.Background(), pkg.QueryParams{
 		Origin:      "SYD",
 		Destination: "BKK",
 		Date:        date,
-		Adults:      "1",
+		Adults:      1,
 	})
 
 	run("No errors", func(t *testing.T) {
@@ -74,3 +74,57 @@ func TestRetrieveFlightOffers(t *testing.T) {
 		testhelpers.AssertJSONEquals(t, filepath.Join("testdata", "offers.json"), flights)
 	})
 }
+
+func TestRetrieveRoundTripBusinessFlightOffers(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.String() {
+		case "/flights/search-roundtrip?adults=1&cabinClass=business&currencyCode=USD&departDate=2025-05-09&fromEntityId=SYD&returnDate=2025-05-16&stops=direct&toEntityId=BKK":
+			var response APIResponse
+			reader := testhelpers.FileToStruct(t, filepath.Join("testdata", "flightsky-offers.json"), &response)
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			run("Method as expected", func(t *testing.T) {
+				assert.Equal(t, http.MethodGet, r.Method)
+			})
+
+			w.WriteHeader(http.StatusOK)
+			w.Write(data)
+		default:
+			t.Errorf("unexpected path %s", r.URL.String())
+			t.FailNow()
+		}
+
+	}))
+	defer testServer.Close()
+
+	mockConfigProvide := func(client infisical.InfisicalClientInterface, projectID string) vendors.Config {
+		return vendors.Config{
+			BaseURL: testServer.URL,
+			APIKey:  "TestAPIKEY",
+		}
+	}
+
+	service := NewService(mockConfigProvide, infisical.NewInfisicalClient(context.Background(), infisical.Config{}), "")
+
+	date, _ := time.Parse("2006-01-02", "2025-05-09")
+	returnDate, _ := time.Parse("2006-01-02", "2025-05-16")
+
+	_, err := service.RetrieveFlightOffers(context.Background(), pkg.QueryParams{
+		Origin:      "SYD",
+		Destination: "BKK",
+		Date:        date,
+		ReturnDate:  returnDate,
+		Adults:      1,
+		Cabin:       pkg.CabinBusiness,
+	})
+
+	run("No errors", func(t *testing.T) {
+		assert.NoError(t, err)
+	})
+}
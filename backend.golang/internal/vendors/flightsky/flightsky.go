@@ -174,4 +174,5 @@ type FlightOffer struct {
 	FlightsSessionID    string      `json:"flightsSessionId"`
 	DestinationImageURL string      `json:"destinationImageUrl"`
 	Token               string      `json:"token"`
+	Currency            string      `json:"-"` // taken from the search criteria
 }
@@ -1,10 +1,13 @@
 package googleflights
 
 import (
+	"context"
 	"encoding/json"
 	"log"
+	"maps"
 	"net/http"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
@@ -43,6 +46,21 @@ type APIResponse struct {
 	SearchParameters SearchParameters `json:"search_parameters"`
 }
 
+// travelClasses maps our cabins to google flights travel classes
+var travelClasses = map[string]string{
+	pkg.CabinEconomy:        "1",
+	pkg.CabinPremiumEconomy: "2",
+	pkg.CabinBusiness:       "3",
+	pkg.CabinFirst:          "4",
+}
+
+// stops maps our maximum number of stops to google flights stops
+var stops = map[int]string{
+	pkg.MaxStopsDirect: "1",
+	pkg.MaxStopsOne:    "2",
+	pkg.MaxStopsAny:    "0",
+}
+
 // Service is a representation of a google flights http client
 type Service struct {
 	config     vendors.Config
@@ -140,7 +158,7 @@ func (s Service) Client() *http.Client {
 }
 
 // RetrieveFlightOffers retrives all available flight offers from google flights
-func (s *Service) RetrieveFlightOffers(params pkg.QueryParams) (FlightOffer, error) {
+func (s *Service) RetrieveFlightOffers(ctx context.Context, params pkg.QueryParams) (FlightOffer, error) {
 	if s.config.Disabled {
 		// TODO:
 		// we might need to disable this client during testing as the library contains hardcoded urls and unnaccesible configs
@@ -149,21 +167,124 @@ func (s *Service) RetrieveFlightOffers(params pkg.QueryParams) (FlightOffer, err
 	}
 
 	var (
-		response APIResponse
-		request  = map[string]string{
+		request = map[string]string{
 			"engine":        "google_flights",
 			"departure_id":  params.Origin,
 			"arrival_id":    params.Destination,
 			"outbound_date": params.Date.Format("2006-01-02"),
-			"adults":        params.Adults,
-			"stops":         "direct", // to keep things simple, only direct flights
-			"currencyCode":  "USD",
-			"type":          "2", // one way
-			"hl":            "en",
+			// google flights has no senior fares, so seniors are searched as adults
+			"adults":   strconv.Itoa(params.Adults + params.Seniors),
+			"currency": params.CurrencyCode(),
+			"type":     "2", // one way
+			"hl":       "en",
 		}
 	)
 
+	if params.Children > 0 {
+		request["children"] = strconv.Itoa(params.Children)
+	}
+
+	if params.InfantsInSeat > 0 {
+		request["infants_in_seat"] = strconv.Itoa(params.InfantsInSeat)
+	}
+
+	if params.InfantsOnLap > 0 {
+		request["infants_on_lap"] = strconv.Itoa(params.InfantsOnLap)
+	}
+
+	request["stops"] = stops[min(params.MaxStops, pkg.MaxStopsAny)]
+
+	if class, ok := travelClasses[params.Cabin]; ok {
+		request["travel_class"] = class
+	}
+
+	if params.IsRoundTrip() {
+		request["type"] = "1" // round trip
+		request["return_date"] = params.ReturnDate.Format("2006-01-02")
+	}
+
+	response, err := s.search(ctx, request)
+	if err != nil {
+		return FlightOffer{}, err
+	}
+
+	if !params.IsRoundTrip() {
+		return response, nil
+	}
+
+	// google only returns outbound flights for round trips, return flights for each one of them are retrieved
+	// through its departure token, so only best flights are expanded to save on api calls and other flights are dropped
+	response.OtherFlights = nil
+	response.BestFlights, err = s.expandReturnFlights(ctx, request, response.BestFlights...)
+	if err != nil {
+		return FlightOffer{}, err
+	}
+
+	return response, nil
+}
+
+// expandReturnFlights retrieves the return flights of every outbound itinerary concurrently, itineraries without
+// a departure token or return flights are left out, so the search only fails when every lookup failed
+func (s *Service) expandReturnFlights(ctx context.Context, request map[string]string, itineraries ...Itinerary) ([]Itinerary, error) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		lookups  = 0
+		failures = 0
+		lastErr  error
+	)
+
+	for i, itinerary := range itineraries {
+		if itinerary.DepartureToken == "" {
+			continue
+		}
+
+		lookups++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			returnRequest := maps.Clone(request)
+			returnRequest["departure_token"] = itinerary.DepartureToken
+			returns, err := s.search(ctx, returnRequest)
+			if err != nil {
+				mu.Lock()
+				failures++
+				lastErr = err
+				mu.Unlock()
+				return
+			}
+
+			// every goroutine writes its own itinerary
+			itineraries[i].ReturnFlights = append(returns.BestFlights, returns.OtherFlights...)
+		}()
+	}
+
+	wg.Wait()
+	if failures > 0 && failures == lookups {
+		return nil, lastErr
+	}
+
+	// an outbound itinerary without return flights would otherwise be taken as a one way offer
+	expanded := []Itinerary{}
+	for _, itinerary := range itineraries {
+		if len(itinerary.ReturnFlights) > 0 {
+			expanded = append(expanded, itinerary)
+		}
+	}
+
+	return expanded, nil
+}
+
+func (s *Service) search(ctx context.Context, request map[string]string) (FlightOffer, error) {
+	var response APIResponse
+
 	search := g.NewGoogleSearch(request, s.config.APIKey)
+	// the library does not take a context, so it is bound to every request through the transport
+	search.HttpSearch = &http.Client{
+		Timeout:   s.httpclient.Timeout,
+		Transport: contextTransport{ctx: ctx, base: s.httpclient.Transport},
+	}
+
 	results, err := search.GetJSON()
 	if err != nil {
 		log.Printf("unable to retrieve flights from google flights, error: %s", err)
@@ -181,5 +302,20 @@ func (s *Service) RetrieveFlightOffers(params pkg.QueryParams) (FlightOffer, err
 		return FlightOffer{}, err
 	}
 
+	response.FlightOffer.Currency = response.SearchParameters.Currency
 	return response.FlightOffer, nil
 }
+
+// contextTransport binds every request to a context, for http clients made by libraries not taking one
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := vendors.BeforeCall(t.ctx); err != nil {
+		return nil, err
+	}
+
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
@@ -0,0 +1,184 @@
+package googleflights
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+// serverTransport sends every request to the test server, as the serpapi library has its url hardcoded
+type serverTransport struct {
+	url *url.URL
+}
+
+func (t serverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme = t.url.Scheme
+	req.URL.Host = t.url.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newTestService(t *testing.T, handler http.HandlerFunc) (Service, func()) {
+	server := httptest.NewServer(handler)
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return Service{
+		config:     vendors.Config{APIKey: "testAPIKey"},
+		httpclient: &http.Client{Timeout: 5 * time.Second, Transport: serverTransport{url: serverURL}},
+	}, server.Close
+}
+
+func writeTestdata(t *testing.T, w http.ResponseWriter, name string) {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.Write(data)
+}
+
+func TestRetrieveFlightOffers(t *testing.T) {
+	run := testhelpers.Run(t)
+	date := time.Date(2025, 5, 9, 0, 0, 0, 0, time.UTC)
+
+	run("Maximum stops are forwarded to google", func(t *testing.T) {
+		service, closeServer := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "2", r.URL.Query().Get("stops"))
+			writeTestdata(t, w, "one-stop-flights.json")
+		})
+		defer closeServer()
+
+		offers, err := service.RetrieveFlightOffers(context.Background(), pkg.QueryParams{Origin: "SYD", Destination: "BKK", Date: date, Adults: 1, MaxStops: pkg.MaxStopsOne})
+		assert.NoError(t, err)
+		assert.Len(t, offers.OtherFlights, 1)
+		assert.Len(t, offers.OtherFlights[0].Layovers, 1)
+	})
+
+	run("Any number of stops is searched beyond one stop", func(t *testing.T) {
+		service, closeServer := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "0", r.URL.Query().Get("stops"))
+			writeTestdata(t, w, "one-stop-flights.json")
+		})
+		defer closeServer()
+
+		_, err := service.RetrieveFlightOffers(context.Background(), pkg.QueryParams{Origin: "SYD", Destination: "BKK", Date: date, Adults: 1, MaxStops: 5})
+		assert.NoError(t, err)
+	})
+
+	roundTrip := pkg.QueryParams{Origin: "SYD", Destination: "BKK", Date: date, ReturnDate: date.AddDate(0, 0, 7), Adults: 1}
+
+	run("Round trips keep the outbound flights expanded", func(t *testing.T) {
+		service, closeServer := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("departure_token") {
+			case "":
+				writeTestdata(t, w, "outbound-flights.json")
+			case "outbound-tg":
+				writeTestdata(t, w, "return-flights.json")
+			default:
+				w.Write([]byte(`{"error": "Google hasn't returned any results for this query."}`))
+			}
+		})
+		defer closeServer()
+
+		offers, err := service.RetrieveFlightOffers(context.Background(), roundTrip)
+		assert.NoError(t, err)
+		assert.Empty(t, offers.OtherFlights)
+		assert.Len(t, offers.BestFlights, 1)
+		assert.Equal(t, "TG 476", offers.BestFlights[0].Flights[0].FlightNumber)
+		assert.Equal(t, "TG 475", offers.BestFlights[0].ReturnFlights[0].Flights[0].FlightNumber)
+	})
+
+	run("Outbound flights without return flights are left out", func(t *testing.T) {
+		service, closeServer := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("departure_token") {
+			case "":
+				writeTestdata(t, w, "outbound-flights.json")
+			case "outbound-tg":
+				writeTestdata(t, w, "return-flights.json")
+			default:
+				w.Write([]byte(`{"best_flights": [], "other_flights": []}`))
+			}
+		})
+		defer closeServer()
+
+		// the VA flight has no departure token and the QF one has no return flights
+		offers, err := service.RetrieveFlightOffers(context.Background(), roundTrip)
+		assert.NoError(t, err)
+		assert.Len(t, offers.BestFlights, 1)
+		assert.Equal(t, "TG 476", offers.BestFlights[0].Flights[0].FlightNumber)
+	})
+
+	run("Round trips fail when no outbound flight is expanded", func(t *testing.T) {
+		service, closeServer := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("departure_token") == "" {
+				writeTestdata(t, w, "outbound-flights.json")
+				return
+			}
+
+			w.Write([]byte(`{"error": "Google hasn't returned any results for this query."}`))
+		})
+		defer closeServer()
+
+		_, err := service.RetrieveFlightOffers(context.Background(), roundTrip)
+		assert.Error(t, err)
+	})
+
+	run("Every call of a round trip runs the call hook", func(t *testing.T) {
+		requests := atomic.Int32{}
+		service, closeServer := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			if r.URL.Query().Get("departure_token") == "" {
+				writeTestdata(t, w, "outbound-flights.json")
+				return
+			}
+
+			writeTestdata(t, w, "return-flights.json")
+		})
+		defer closeServer()
+
+		calls := atomic.Int32{}
+		ctx := vendors.WithCallHook(context.Background(), func(ctx context.Context) error {
+			calls.Add(1)
+			return nil
+		})
+
+		offers, err := service.RetrieveFlightOffers(ctx, roundTrip)
+		assert.NoError(t, err)
+		assert.Len(t, offers.BestFlights, 2)
+		// the outbound search, plus the return flights of both best flights
+		assert.Equal(t, int32(3), calls.Load())
+		assert.Equal(t, int32(3), requests.Load())
+	})
+
+	run("Calls stopped by the call hook are not sent", func(t *testing.T) {
+		requests := atomic.Int32{}
+		service, closeServer := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			writeTestdata(t, w, "outbound-flights.json")
+		})
+		defer closeServer()
+
+		errStopped := errors.New("stopped")
+		ctx := vendors.WithCallHook(context.Background(), func(ctx context.Context) error {
+			return errStopped
+		})
+
+		_, err := service.RetrieveFlightOffers(ctx, roundTrip)
+		assert.ErrorIs(t, err, errStopped)
+		assert.Zero(t, requests.Load())
+	})
+}
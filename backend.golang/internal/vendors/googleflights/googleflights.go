@@ -45,18 +45,29 @@ type Flight struct {
 	Legroom          string      `json:"legroom"`
 	Extensions       []string    `json:"extensions"`
 	Overnight        bool        `json:"overnight,omitempty"`
+	PlaneAndCrewBy   string      `json:"plane_and_crew_by,omitempty"` // only present when operated by a different airline
+}
+
+// Layover represents a connection between two flights of an itinerary in google flights
+type Layover struct {
+	Duration  int    `json:"duration"` // in minutes
+	Name      string `json:"name"`
+	ID        string `json:"id"`
+	Overnight bool   `json:"overnight,omitempty"`
 }
 
 // Itinerary represents a breakdown of a flight itinerary
 type Itinerary struct {
 	Flights         []Flight           `json:"flights"`
-	Layovers        []any              `json:"layovers"`
+	Layovers        []Layover          `json:"layovers"`
 	TotalDuration   int                `json:"total_duration"`
 	CarbonEmissions CarbonEmissionInfo `json:"carbon_emissions"`
 	Price           float64            `json:"price"`
 	Type            string             `json:"type"`
 	AirlineLogo     string             `json:"airline_logo"`
 	BookingToken    string             `json:"booking_token"`
+	DepartureToken  string             `json:"departure_token,omitempty"`
+	ReturnFlights   []Itinerary        `json:"return_flights,omitempty"` // only present on round trips, filled through the departure token
 }
 
 // Price represents a price for historical record
@@ -85,4 +96,5 @@ type FlightOffer struct {
 	OtherFlights  []Itinerary  `json:"other_flights"`
 	PriceInsights PriceSummary `json:"price_insights"`
 	Airports      []any        `json:"airports"`
+	Currency      string       `json:"-"` // taken from the search parameters
 }
@@ -3,8 +3,10 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -14,7 +16,6 @@ import (
 // Service provides functionality to interact with redis
 type Service struct {
 	disabled bool
-	ctx      context.Context
 	rdb      *redis.Client
 }
 
@@ -22,15 +23,21 @@ type Service struct {
 func NewRedisService(disabled bool) Service {
 	return Service{
 		disabled: disabled,
-		ctx:      context.Background(),
 		rdb: redis.NewClient(&redis.Options{
 			Addr: os.Getenv("REDIS_URL"), // service name in docker-compose
 		}),
 	}
 }
 
-// CacheBestFlightResponse stores best flight response for 30 sec, using the set of params as id
-func (s Service) CacheBestFlightResponse(searchCriteria string, data pkg.GetBestFlightOffersResponse) error {
+// VendorOffers are the offers found by every vendor on a search, already converted and merged yet neither filtered
+// nor ranked, so every search sharing the same vendor criteria is served from them
+type VendorOffers struct {
+	Offers  []pkg.FlightOffer  `json:"offers"`
+	Vendors []pkg.VendorReport `json:"vendors"`
+}
+
+// CacheVendorOffers stores the vendor offers of a search for 30 sec, using the search criteria sent to vendors as id
+func (s Service) CacheVendorOffers(ctx context.Context, searchCriteria string, data VendorOffers) error {
 	if s.disabled {
 		return nil
 	}
@@ -40,18 +47,18 @@ func (s Service) CacheBestFlightResponse(searchCriteria string, data pkg.GetBest
 		return err
 	}
 
-	return s.rdb.Set(s.ctx, searchCriteria, bodyBytes, 30*time.Second).Err()
+	return s.rdb.Set(ctx, searchCriteria, bodyBytes, 30*time.Second).Err()
 }
 
-// CacheBestFlightResponse restores best flight response, using the set of params as id
-func (s Service) GetCachedBestFlightResponse(searchCriteria string) (*pkg.GetBestFlightOffersResponse, error) {
+// GetCachedVendorOffers restores the vendor offers of a search, using the search criteria sent to vendors as id
+func (s Service) GetCachedVendorOffers(ctx context.Context, searchCriteria string) (*VendorOffers, error) {
 	if s.disabled {
 		return nil, nil
 	}
 
-	var response pkg.GetBestFlightOffersResponse
+	var response VendorOffers
 
-	bodyBytes, err := s.rdb.Get(s.ctx, searchCriteria).Bytes()
+	bodyBytes, err := s.rdb.Get(ctx, searchCriteria).Bytes()
 	// means not found
 	if err == redis.Nil {
 		log.Println("no redis cache found")
@@ -68,3 +75,171 @@ func (s Service) GetCachedBestFlightResponse(searchCriteria string) (*pkg.GetBes
 
 	return &response, nil
 }
+
+// RecordCalendarFare stores the lowest fare found by a vendor for a route and departure date,
+// fares are grouped per month and kept for a week so the price calendar is able to fill days nobody searched recently
+func (s Service) RecordCalendarFare(ctx context.Context, origin, destination string, fare pkg.CalendarDay) error {
+	if s.disabled {
+		return nil
+	}
+
+	bodyBytes, err := json.Marshal(fare)
+	if err != nil {
+		return err
+	}
+
+	key := calendarKey(origin, destination, fare.Date)
+	field := fmt.Sprintf("%s:%s", fare.Date.Format("2006-01-02"), fare.Source)
+	if err := s.rdb.HSet(ctx, key, field, bodyBytes).Err(); err != nil {
+		return err
+	}
+
+	return s.rdb.Expire(ctx, key, 7*24*time.Hour).Err()
+}
+
+// GetCalendarFares restores all the fares recorded for a route within a month
+func (s Service) GetCalendarFares(ctx context.Context, origin, destination string, month time.Time) ([]pkg.CalendarDay, error) {
+	fares := []pkg.CalendarDay{}
+	if s.disabled {
+		return fares, nil
+	}
+
+	values, err := s.rdb.HGetAll(ctx, calendarKey(origin, destination, month)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range values {
+		var fare pkg.CalendarDay
+		if err := json.Unmarshal([]byte(value), &fare); err != nil {
+			return nil, err
+		}
+
+		fares = append(fares, fare)
+	}
+
+	return fares, nil
+}
+
+func calendarKey(origin, destination string, month time.Time) string {
+	return fmt.Sprintf("calendar:%s-%s:%s", origin, destination, month.Format("2006-01"))
+}
+
+// StoreBestFlightSnapshot stores a whole best flight response for 30 min, so paging through it is consistent
+// even after the cached search expires
+func (s Service) StoreBestFlightSnapshot(ctx context.Context, id string, data pkg.GetBestFlightOffersResponse) error {
+	if s.disabled {
+		return nil
+	}
+
+	bodyBytes, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return s.rdb.Set(ctx, snapshotKey(id), bodyBytes, 30*time.Minute).Err()
+}
+
+// GetBestFlightSnapshot restores a best flight response snapshot, nil when it expired
+func (s Service) GetBestFlightSnapshot(ctx context.Context, id string) (*pkg.GetBestFlightOffersResponse, error) {
+	if s.disabled {
+		return nil, nil
+	}
+
+	var response pkg.GetBestFlightOffersResponse
+
+	bodyBytes, err := s.rdb.Get(ctx, snapshotKey(id)).Bytes()
+	// means not found
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(bodyBytes, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+func snapshotKey(id string) string {
+	return fmt.Sprintf("snapshot:%s", id)
+}
+
+// QuotaCounter counts the calls made to a vendor within a time window, e.g the calls made today
+type QuotaCounter struct {
+	Key   string
+	Limit int64
+	TTL   time.Duration // counters expire along with their window
+}
+
+// consumeQuotaScript checks every counter before incrementing any of them, so a call is either counted on all of
+// them or on none, returns the index of the first counter exhausted or 0 when the call was counted
+var consumeQuotaScript = redis.NewScript(`
+for i, key in ipairs(KEYS) do
+	local used = tonumber(redis.call("GET", key) or "0")
+	if used >= tonumber(ARGV[i * 2 - 1]) then
+		return i
+	end
+end
+
+for i, key in ipairs(KEYS) do
+	if redis.call("INCR", key) == 1 then
+		redis.call("PEXPIRE", key, ARGV[i * 2])
+	end
+end
+
+return 0
+`)
+
+// ConsumeQuota counts a call on every counter unless any of them already reached its limit, counters live in redis
+// so every instance shares the same limits
+// Returns the index of the first counter exhausted, or -1 when the call was counted
+func (s Service) ConsumeQuota(ctx context.Context, counters ...QuotaCounter) (int, error) {
+	if s.disabled || len(counters) == 0 {
+		return -1, nil
+	}
+
+	keys := make([]string, 0, len(counters))
+	args := make([]any, 0, len(counters)*2)
+	for _, counter := range counters {
+		keys = append(keys, counter.Key)
+		args = append(args, counter.Limit, counter.TTL.Milliseconds())
+	}
+
+	exhausted, err := consumeQuotaScript.Run(ctx, s.rdb, keys, args...).Int()
+	if err != nil {
+		return -1, err
+	}
+
+	return exhausted - 1, nil
+}
+
+// GetQuotaUsage returns the calls counted on every key, zero for counters that expired
+func (s Service) GetQuotaUsage(ctx context.Context, keys ...string) ([]int64, error) {
+	usage := make([]int64, len(keys))
+	if s.disabled || len(keys) == 0 {
+		return usage, nil
+	}
+
+	values, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, value := range values {
+		raw, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		if usage[i], err = strconv.ParseInt(raw, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	return usage, nil
+}
@@ -0,0 +1,71 @@
+package vendors
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy describes how failed requests to a vendor are retried,
+// only network errors, 429 and 5xx responses are retried
+type RetryPolicy struct {
+	MaxAttempts int           // attempts including the first one, requests are not retried below 2
+	BaseDelay   time.Duration // delay before the first retry, doubled on every attempt
+	MaxDelay    time.Duration // upper bound of every delay, a longer Retry-After gives up retrying
+}
+
+// DefaultRetryPolicy is used by every vendor unless its config says otherwise
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+	}
+}
+
+// delay returns how long to wait before the next attempt,
+// exponential backoff with full jitter unless the vendor asked for a delay through Retry-After
+func (p RetryPolicy) delay(attempt int, retryAfter time.Duration) (time.Duration, bool) {
+	if retryAfter > 0 {
+		return retryAfter, p.MaxDelay <= 0 || retryAfter <= p.MaxDelay
+	}
+
+	backoff := p.BaseDelay << (attempt - 1)
+	if p.MaxDelay > 0 && (backoff > p.MaxDelay || backoff <= 0) {
+		backoff = p.MaxDelay
+	}
+
+	if backoff <= 0 {
+		return 0, true
+	}
+
+	return rand.N(backoff) + 1, true
+}
+
+// parseRetryAfter reads a Retry-After header, either in seconds or as an http date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
+
+// isIdempotent reports whether a request with the method is safe to send more than once
+func isIdempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
@@ -2,6 +2,7 @@ package vendors
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -25,12 +27,14 @@ type Config struct {
 	APIKey       string
 	ClientID     string
 	ClientSecret string
+	Retry        RetryPolicy
 }
 
 // Service represents a generic http service interface
 type Service interface {
 	Authenticate(req *http.Request) error
 	Client() *http.Client
+	RetryPolicy() RetryPolicy
 }
 
 // Request is a request representation for integrations
@@ -40,8 +44,31 @@ type Request struct {
 	BaseURL     string
 	Resource    string
 	Method      string
+	Headers     http.Header
 	Params      url.Values
 	Payload     any
+	// Retryable allows retrying non idempotent methods, e.g a POST only used to query the vendor
+	Retryable bool
+}
+
+// CallHook runs right before every http call sent to a vendor, retries included, an error stops the call
+type CallHook func(ctx context.Context) error
+
+type callHookKey struct{}
+
+// WithCallHook returns a context running the hook before every vendor call made with it
+func WithCallHook(ctx context.Context, hook CallHook) context.Context {
+	return context.WithValue(ctx, callHookKey{}, hook)
+}
+
+// BeforeCall runs the hook bound to the context, if any
+func BeforeCall(ctx context.Context) error {
+	hook, ok := ctx.Value(callHookKey{}).(CallHook)
+	if !ok {
+		return nil
+	}
+
+	return hook(ctx)
 }
 
 func (r Request) URL() string {
@@ -75,7 +102,8 @@ func generateURLEncodedBody(payload any) (io.Reader, error) {
 }
 
 // MakeHTTPRequest build, send and decode HTTP request/response made to an external service,
-func MakeHTTPRequest(v Service, request Request, resp any) error {
+// failed attempts are retried following the retry policy of the service
+func MakeHTTPRequest(ctx context.Context, v Service, request Request, resp any) error {
 	var (
 		body io.Reader
 		err  error
@@ -94,33 +122,99 @@ func MakeHTTPRequest(v Service, request Request, resp any) error {
 		return errors.Wrapf(err, "client - unable to generate payload for content %s", request.ContentType)
 	}
 
+	// the payload is kept in memory, so every attempt sends the same body
+	var payload []byte
+	if body != nil {
+		if payload, err = io.ReadAll(body); err != nil {
+			return errors.Wrap(err, "client - unable to read request body")
+		}
+	}
+
+	policy := v.RetryPolicy()
+	if !request.Retryable && !isIdempotent(request.Method) {
+		policy.MaxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		res, err := makeHTTPAttempt(ctx, v, request, payload)
+		if err == nil {
+			return decodeResponse(res, resp)
+		}
+
+		if !err.retryable || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return err.err
+		}
+
+		delay, ok := policy.delay(attempt, err.retryAfter)
+		if !ok {
+			return err.err
+		}
+
+		log.Printf("attempt %d to route %s failed, retrying in %s, error: %s", attempt, request.URL(), delay, err.err)
+
+		select {
+		case <-ctx.Done():
+			return err.err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// attemptError is the failure of a single attempt, along with whether it is worth retrying
+type attemptError struct {
+	err        error
+	retryable  bool
+	retryAfter time.Duration
+}
+
+type attemptResponse struct {
+	statusCode int
+	body       []byte
+}
+
+func makeHTTPAttempt(ctx context.Context, v Service, request Request, payload []byte) (attemptResponse, *attemptError) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
 	log.Printf("making request to route %s", request.URL())
 
 	// generate a new http request object
-	req, err := http.NewRequest(request.Method, request.URL(), body)
+	req, err := http.NewRequestWithContext(ctx, request.Method, request.URL(), body)
 	if err != nil {
-		return errors.Wrap(err, "client - unable to create request body")
+		return attemptResponse{}, &attemptError{err: errors.Wrap(err, "client - unable to create request body")}
 	}
 
 	req.Header.Add("Content-Type", request.ContentType)
+	for key, values := range request.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
 
 	// sets authentication headers to request
 	if !request.SkipAuth {
 		if err := v.Authenticate(req); err != nil {
-			return err
+			return attemptResponse{}, &attemptError{err: err}
 		}
 	}
 
-	// make an http call to the outlaying service
+	if err := BeforeCall(ctx); err != nil {
+		return attemptResponse{}, &attemptError{err: err}
+	}
+
+	// make an http call to the outlaying service, network errors are worth retrying
 	res, err := v.Client().Do(req)
 	if err != nil {
-		return errors.Wrap(err, "client - failed to execute request")
+		return attemptResponse{}, &attemptError{err: errors.Wrap(err, "client - failed to execute request"), retryable: true}
 	}
+	defer res.Body.Close()
 
 	// read our response
 	b, err := io.ReadAll(res.Body)
 	if err != nil && err != io.EOF {
-		return errors.Wrap(err, "client - unable to read response body")
+		return attemptResponse{}, &attemptError{err: errors.Wrap(err, "client - unable to read response body"), retryable: true}
 	}
 
 	// validate response
@@ -132,18 +226,26 @@ func MakeHTTPRequest(v Service, request Request, resp any) error {
 	}
 
 	if !validResponses[res.StatusCode] {
-		return fmt.Errorf("invalid status code received, expected 200/204/201/202, got %v with body %s", res.StatusCode, b)
+		return attemptResponse{}, &attemptError{
+			err:        fmt.Errorf("invalid status code received, expected 200/204/201/202, got %v with body %s", res.StatusCode, b),
+			retryable:  res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError,
+			retryAfter: parseRetryAfter(res.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
+	return attemptResponse{statusCode: res.StatusCode, body: b}, nil
+}
+
+func decodeResponse(res attemptResponse, resp any) error {
 	// do not unmarshal response on 204 or empty response
-	if res.StatusCode == http.StatusNoContent || len(b) == 0 {
-		fmt.Printf("got response %s code %d", string(b), res.StatusCode)
+	if res.statusCode == http.StatusNoContent || len(res.body) == 0 {
+		fmt.Printf("got response %s code %d", string(res.body), res.statusCode)
 		return nil
 	}
 
 	// decode our response payload
-	if err := json.NewDecoder(bytes.NewReader(b)).Decode(&resp); err != nil {
-		fmt.Printf("got response %s code %d", string(b), res.StatusCode)
+	if err := json.NewDecoder(bytes.NewReader(res.body)).Decode(&resp); err != nil {
+		fmt.Printf("got response %s code %d", string(res.body), res.statusCode)
 		return errors.Wrap(err, "unable to unmarshal response body")
 	}
 
@@ -0,0 +1,129 @@
+package vendors_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubService struct {
+	retry vendors.RetryPolicy
+}
+
+func (s stubService) Authenticate(req *http.Request) error {
+	return nil
+}
+
+func (s stubService) Client() *http.Client {
+	return http.DefaultClient
+}
+
+func (s stubService) RetryPolicy() vendors.RetryPolicy {
+	return s.retry
+}
+
+// mockFlakyServer fails with the status until it was called the number of failures
+func mockFlakyServer(failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	calls := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			for key := range header {
+				w.Header().Set(key, header.Get(key))
+			}
+			w.WriteHeader(status)
+			return
+		}
+
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+
+	return server, calls
+}
+
+func TestMakeHTTPRequestRetries(t *testing.T) {
+	run := testhelpers.Run(t)
+	service := stubService{retry: vendors.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}}
+
+	run("Transient errors are retried", func(t *testing.T) {
+		server, calls := mockFlakyServer(2, http.StatusBadGateway, nil)
+		defer server.Close()
+
+		response := map[string]string{}
+		err := vendors.MakeHTTPRequest(context.Background(), service, vendors.Request{BaseURL: server.URL, Method: http.MethodGet}, &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", response["status"])
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	run("Attempts are bounded by the policy", func(t *testing.T) {
+		server, calls := mockFlakyServer(5, http.StatusTooManyRequests, nil)
+		defer server.Close()
+
+		err := vendors.MakeHTTPRequest(context.Background(), service, vendors.Request{BaseURL: server.URL, Method: http.MethodGet}, &map[string]string{})
+		assert.ErrorContains(t, err, "got 429")
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	run("Client errors are not retried", func(t *testing.T) {
+		server, calls := mockFlakyServer(1, http.StatusBadRequest, nil)
+		defer server.Close()
+
+		err := vendors.MakeHTTPRequest(context.Background(), service, vendors.Request{BaseURL: server.URL, Method: http.MethodGet}, &map[string]string{})
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	run("Non idempotent requests are only retried when allowed", func(t *testing.T) {
+		server, calls := mockFlakyServer(2, http.StatusServiceUnavailable, nil)
+		defer server.Close()
+
+		request := vendors.Request{BaseURL: server.URL, Method: http.MethodPost, ContentType: vendors.ContentTypeJSON, Payload: map[string]string{"a": "b"}}
+		err := vendors.MakeHTTPRequest(context.Background(), service, request, &map[string]string{})
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), calls.Load())
+
+		request.Retryable = true
+		err = vendors.MakeHTTPRequest(context.Background(), service, request, &map[string]string{})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	run("Retry-After longer than the max delay is not waited for", func(t *testing.T) {
+		server, calls := mockFlakyServer(1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"120"}})
+		defer server.Close()
+
+		err := vendors.MakeHTTPRequest(context.Background(), service, vendors.Request{BaseURL: server.URL, Method: http.MethodGet}, &map[string]string{})
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	run("Retry-After is honoured", func(t *testing.T) {
+		server, calls := mockFlakyServer(1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"1"}})
+		defer server.Close()
+
+		service := stubService{retry: vendors.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}}
+		start := time.Now()
+		err := vendors.MakeHTTPRequest(context.Background(), service, vendors.Request{BaseURL: server.URL, Method: http.MethodGet}, &map[string]string{})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), calls.Load())
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	})
+
+	run("Cancelled requests stop retrying", func(t *testing.T) {
+		server, calls := mockFlakyServer(5, http.StatusBadGateway, nil)
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := vendors.MakeHTTPRequest(ctx, service, vendors.Request{BaseURL: server.URL, Method: http.MethodGet}, &map[string]string{})
+		assert.Error(t, err)
+		assert.Equal(t, int32(0), calls.Load())
+	})
+}
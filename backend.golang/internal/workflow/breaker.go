@@ -0,0 +1,43 @@
+package workflow
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/rubengp99/golang-flights-challenge/internal/breaker"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// ErrCircuitOpen is reported for vendors skipped because their circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// guardVendorRequest skips the request right away while the circuit breaker of its vendor is open,
+// otherwise its outcome is recorded on the breaker
+func guardVendorRequest(breakers *breaker.Breakers, request vendorRequest) vendorRequest {
+	if breakers == nil {
+		return request
+	}
+
+	circuit := breakers.Breaker(request.source)
+	search := request.search
+	request.search = func(ctx context.Context) ([]pkg.FlightOffer, []pkg.CalendarDay, error) {
+		if !circuit.Allow() {
+			return nil, nil, errors.Wrap(ErrCircuitOpen, request.source)
+		}
+
+		offers, fares, err := search(ctx)
+		switch {
+		case err == nil:
+			circuit.Success()
+		case ctx.Err() != nil, errors.Is(err, ErrQuotaExhausted):
+			// the search was cancelled or never sent, which tells nothing about the vendor
+			circuit.Release()
+		default:
+			circuit.Failure()
+		}
+
+		return offers, fares, err
+	}
+
+	return request
+}
@@ -0,0 +1,76 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/breaker"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFailingVendorRequest(source string, calls *int) vendorRequest {
+	return vendorRequest{source: source, search: func(ctx context.Context) ([]pkg.FlightOffer, []pkg.CalendarDay, error) {
+		*calls++
+		return nil, nil, errors.New("unexpected status 502")
+	}}
+}
+
+func TestGuardVendorRequest(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	run("Open circuits skip the vendor", func(t *testing.T) {
+		calls := 0
+		breakers := breaker.New(breaker.Config{FailureThreshold: 2, CoolDown: time.Minute}, time.Now)
+		config := Config{Breakers: breakers}
+		request := guardVendorRequest(breakers, newFailingVendorRequest(pkg.SourceFlightsky, &calls))
+
+		for i := 0; i < 3; i++ {
+			results, _, err := searchVendors(context.Background(), config, request)
+			assert.NoError(t, err)
+			assert.Error(t, results[0].err)
+		}
+
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, pkg.BreakerOpen, breakers.Breaker(pkg.SourceFlightsky).Status().State)
+
+		results, _, _ := searchVendors(context.Background(), config, request)
+		assert.ErrorIs(t, results[0].err, ErrCircuitOpen)
+	})
+
+	run("Successful vendors stay closed", func(t *testing.T) {
+		breakers := breaker.New(breaker.Config{FailureThreshold: 1, CoolDown: time.Minute}, time.Now)
+		request := guardVendorRequest(breakers, newDelayedVendorRequest(pkg.SourceAmadeus, 0, 2))
+
+		results, _, err := searchVendors(context.Background(), Config{Breakers: breakers}, request)
+		assert.NoError(t, err)
+		assert.Len(t, results[0].offers, 2)
+		assert.Equal(t, pkg.BreakerClosed, breakers.Breaker(pkg.SourceAmadeus).Status().State)
+	})
+
+	run("Vendors skipped for their quota stay closed", func(t *testing.T) {
+		breakers := breaker.New(breaker.Config{FailureThreshold: 1, CoolDown: time.Minute}, time.Now)
+		request := guardVendorRequest(breakers, vendorRequest{source: pkg.SourceGoogleflights, search: func(ctx context.Context) ([]pkg.FlightOffer, []pkg.CalendarDay, error) {
+			return nil, nil, fmt.Errorf("googleflights: day %w", ErrQuotaExhausted)
+		}})
+
+		results, _, err := searchVendors(context.Background(), Config{Breakers: breakers}, request)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, results[0].err, ErrQuotaExhausted)
+		assert.Equal(t, pkg.BreakerClosed, breakers.Breaker(pkg.SourceGoogleflights).Status().State)
+	})
+
+	run("Requests are not guarded without breakers", func(t *testing.T) {
+		calls := 0
+		request := guardVendorRequest(nil, newFailingVendorRequest(pkg.SourceFlightsky, &calls))
+		for i := 0; i < 3; i++ {
+			_, _, _ = searchVendors(context.Background(), Config{}, request)
+		}
+
+		assert.Equal(t, 3, calls)
+	})
+}
@@ -0,0 +1,168 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// DefaultSearchBudget is how long a search waits for vendors before returning the offers found so far
+const DefaultSearchBudget = 10 * time.Second
+
+// vendorRequest is a single search sent to a vendor, e.g amadeus searching a single airport pair
+type vendorRequest struct {
+	source string
+	search func(ctx context.Context) ([]pkg.FlightOffer, []pkg.CalendarDay, error)
+}
+
+// vendorResult is what a vendor request found, fares are the calendar fares seen along the way
+type vendorResult struct {
+	source   string
+	offers   []pkg.FlightOffer
+	fares    []pkg.CalendarDay
+	latency  time.Duration
+	err      error
+	timedOut bool
+}
+
+// ParseVendorDeadlines parses per vendor deadlines as comma separated vendor=duration pairs, e.g amadeus=6s,flightsky=4s
+func ParseVendorDeadlines(value string) (map[string]time.Duration, error) {
+	deadlines := map[string]time.Duration{}
+	for _, pair := range strings.Split(value, ",") {
+		source, rawDeadline, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("workflow - vendor deadlines should be formatted as vendor=duration, got %q", pair)
+		}
+
+		deadline, err := time.ParseDuration(rawDeadline)
+		if err != nil || deadline <= 0 {
+			return nil, fmt.Errorf("workflow - vendor deadline for %s should be a positive duration, got %q", source, rawDeadline)
+		}
+
+		deadlines[source] = deadline
+	}
+
+	return deadlines, nil
+}
+
+// vendorDeadline is how long a vendor is waited for, never longer than the whole search budget
+// Zero means vendors are waited for as long as they take
+func (c Config) vendorDeadline(source string) time.Duration {
+	deadline, ok := c.VendorDeadlines[source]
+	if !ok || (c.SearchBudget > 0 && deadline > c.SearchBudget) {
+		return c.SearchBudget
+	}
+
+	return deadline
+}
+
+// searchVendors sends every request concurrently and returns the results arriving within their vendor deadline and
+// the search budget, requests still running are reported as timed out
+// When any of them timed out, every result is delivered later on through the returned channel once all of them finish
+// Cancelling the context while searching cancels every request, yet requests timing out are left running afterwards
+func searchVendors(ctx context.Context, config Config, requests ...vendorRequest) ([]vendorResult, <-chan []vendorResult, error) {
+	// vendor requests outlive the search when they time out, so they are only cancelled along with it while searching
+	searchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, cancel)
+
+	var (
+		wgdone   = make(chan bool)
+		wg       sync.WaitGroup // every request either finished or went past its deadline
+		finished sync.WaitGroup // every request finished
+		mu       sync.Mutex
+		results  = make([]vendorResult, len(requests))
+		arrived  = make([]bool, len(requests))
+		start    = time.Now()
+	)
+
+	wg.Add(len(requests))
+	finished.Add(len(requests))
+	for i, request := range requests {
+		done := make(chan bool)
+		deadline := config.vendorDeadline(request.source)
+		go func() {
+			defer finished.Done()
+			defer close(done)
+			offers, fares, err := request.search(searchCtx)
+			latency := time.Since(start)
+			mu.Lock()
+			results[i] = vendorResult{source: request.source, offers: offers, fares: fares, latency: latency, err: err}
+			arrived[i] = deadline <= 0 || latency <= deadline
+			mu.Unlock()
+		}()
+
+		go func() {
+			defer wg.Done()
+			select {
+			case <-done:
+			case <-after(deadline):
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(wgdone)
+	}()
+
+	select {
+	case <-wgdone:
+		break
+	case <-after(config.SearchBudget):
+		break
+	case <-ctx.Done():
+		cancel()
+		return nil, nil, ctx.Err()
+	}
+
+	stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	timedOut := false
+	current := []vendorResult{}
+	for i, request := range requests {
+		if arrived[i] {
+			current = append(current, results[i])
+			continue
+		}
+
+		timedOut = true
+		current = append(current, vendorResult{
+			source:   request.source,
+			latency:  time.Since(start),
+			err:      fmt.Errorf("%s timed out after %s", request.source, time.Since(start).Round(time.Millisecond)),
+			timedOut: true,
+		})
+	}
+
+	if !timedOut {
+		cancel()
+		return current, nil, nil
+	}
+
+	late := make(chan []vendorResult, 1)
+	go func() {
+		defer cancel()
+		finished.Wait()
+		mu.Lock()
+		late <- results
+		mu.Unlock()
+	}()
+
+	return current, late, nil
+}
+
+// after fires once the duration elapses, never firing for a zero duration
+func after(d time.Duration) <-chan time.Time {
+	if d <= 0 {
+		return nil
+	}
+
+	return time.After(d)
+}
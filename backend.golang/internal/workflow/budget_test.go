@@ -0,0 +1,99 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDelayedVendorRequest(source string, delay time.Duration, offers int) vendorRequest {
+	return vendorRequest{source: source, search: func(ctx context.Context) ([]pkg.FlightOffer, []pkg.CalendarDay, error) {
+		time.Sleep(delay)
+		return make([]pkg.FlightOffer, offers), nil, nil
+	}}
+}
+
+func TestSearchVendors(t *testing.T) {
+	fast := newDelayedVendorRequest(pkg.SourceAmadeus, 0, 2)
+	slow := newDelayedVendorRequest(pkg.SourceFlightsky, 200*time.Millisecond, 3)
+
+	run := testhelpers.Run(t)
+
+	run("Every vendor within budget", func(t *testing.T) {
+		results, late, err := searchVendors(context.Background(), Config{SearchBudget: time.Second}, fast, slow)
+		assert.NoError(t, err)
+		assert.Nil(t, late)
+		assert.Len(t, results, 2)
+		assert.Len(t, results[1].offers, 3)
+	})
+
+	run("Slow vendors time out on the search budget", func(t *testing.T) {
+		start := time.Now()
+		results, late, err := searchVendors(context.Background(), Config{SearchBudget: 50 * time.Millisecond}, fast, slow)
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), 200*time.Millisecond)
+		assert.False(t, results[0].timedOut)
+		assert.True(t, results[1].timedOut)
+		assert.Error(t, results[1].err)
+		assert.Empty(t, results[1].offers)
+
+		all := <-late
+		assert.Len(t, all[1].offers, 3)
+		assert.NoError(t, all[1].err)
+	})
+
+	run("Slow vendors time out on their own deadline", func(t *testing.T) {
+		config := Config{
+			SearchBudget:    time.Second,
+			VendorDeadlines: map[string]time.Duration{pkg.SourceFlightsky: 50 * time.Millisecond},
+		}
+
+		start := time.Now()
+		results, late, err := searchVendors(context.Background(), config, fast, slow)
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), 200*time.Millisecond)
+		assert.True(t, results[1].timedOut)
+		assert.NotNil(t, late)
+	})
+
+	run("Cancelling the search cancels every vendor", func(t *testing.T) {
+		cancelled := make(chan bool)
+		blocked := vendorRequest{source: pkg.SourceGoogleflights, search: func(ctx context.Context) ([]pkg.FlightOffer, []pkg.CalendarDay, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return nil, nil, ctx.Err()
+		}}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, late, err := searchVendors(ctx, Config{SearchBudget: time.Second}, fast, blocked)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Nil(t, late)
+		<-cancelled
+	})
+}
+
+func TestParseVendorDeadlines(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	run("Deadlines per vendor", func(t *testing.T) {
+		deadlines, err := ParseVendorDeadlines("amadeus=6s, flightsky=1500ms")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]time.Duration{
+			pkg.SourceAmadeus:   6 * time.Second,
+			pkg.SourceFlightsky: 1500 * time.Millisecond,
+		}, deadlines)
+	})
+
+	run("Invalid deadlines", func(t *testing.T) {
+		for _, value := range []string{"amadeus", "amadeus=fast", "amadeus=-1s"} {
+			_, err := ParseVendorDeadlines(value)
+			assert.Error(t, err, value)
+		}
+	})
+}
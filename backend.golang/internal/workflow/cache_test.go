@@ -0,0 +1,199 @@
+package workflow_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rubengp99/golang-flights-challenge/internal/exchange"
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/providers"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/redis"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/internal/workflow"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingProvider finds a cheap and an expensive offer, counting every search sent to it
+type countingProvider struct {
+	searches *atomic.Int32
+}
+
+func (p countingProvider) Source() string {
+	return pkg.SourceFlightsky
+}
+
+func (p countingProvider) Capabilities() providers.Capabilities {
+	return providers.Capabilities{}
+}
+
+func (p countingProvider) RetrieveFlightOffers(ctx context.Context, params pkg.QueryParams) ([]pkg.FlightOffer, error) {
+	p.searches.Add(1)
+	offers := []pkg.FlightOffer{}
+	for i, price := range []float64{300, 900} {
+		departure := pkg.Location{IataCode: params.Origin, Timestamp: params.Date.Add(time.Duration(8+i) * time.Hour)}
+		arrival := pkg.Location{IataCode: params.Destination, Timestamp: departure.Timestamp.Add(9 * time.Hour)}
+		flightNumber := []string{"476", "478"}[i]
+		offers = append(offers, pkg.FlightOffer{
+			Itinerary: pkg.Itinerary{
+				Airline:           "TG",
+				FlightNumber:      "TG " + flightNumber,
+				Departure:         departure,
+				Arrival:           arrival,
+				DurationInMinutes: 540,
+				Segments: []pkg.Segment{{
+					MarketingCarrier: pkg.Carrier{Code: "TG"},
+					FlightNumber:     flightNumber,
+					Departure:        departure,
+					Arrival:          arrival,
+				}},
+			},
+			Price: pkg.Amount{Value: price, Currency: pkg.DefaultCurrency},
+		})
+	}
+
+	return offers, nil
+}
+
+// meteredProvider sends a single vendor call per search, counting the searches actually sent
+type meteredProvider struct {
+	searches *atomic.Int32
+}
+
+func (p meteredProvider) Source() string {
+	return pkg.SourceAmadeus
+}
+
+func (p meteredProvider) Capabilities() providers.Capabilities {
+	return providers.Capabilities{}
+}
+
+func (p meteredProvider) RetrieveFlightOffers(ctx context.Context, params pkg.QueryParams) ([]pkg.FlightOffer, error) {
+	if err := vendors.BeforeCall(ctx); err != nil {
+		return nil, err
+	}
+
+	p.searches.Add(1)
+	departure := pkg.Location{IataCode: params.Origin, Timestamp: params.Date.Add(20 * time.Hour)}
+	arrival := pkg.Location{IataCode: params.Destination, Timestamp: departure.Timestamp.Add(9 * time.Hour)}
+	return []pkg.FlightOffer{{
+		Itinerary: pkg.Itinerary{
+			Airline:           "QF",
+			FlightNumber:      "QF 23",
+			Departure:         departure,
+			Arrival:           arrival,
+			DurationInMinutes: 540,
+			Segments: []pkg.Segment{{
+				MarketingCarrier: pkg.Carrier{Code: "QF"},
+				FlightNumber:     "23",
+				Departure:        departure,
+				Arrival:          arrival,
+			}},
+		},
+		Price: pkg.Amount{Value: 500, Currency: pkg.DefaultCurrency},
+	}}, nil
+}
+
+func TestRetrieveBestFlightsCache(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	t.Setenv("REDIS_URL", miniredis.RunT(t).Addr())
+	rates, err := exchange.DefaultFileProvider()()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	searches := &atomic.Int32{}
+	registry := providers.NewRegistry(countingProvider{searches: searches})
+	config := workflow.Config{RankingWeights: mapping.DefaultRankingWeights, VendorPolicy: workflow.DefaultVendorPolicy}
+	retrieve := workflow.RetrieveBestFlights(redis.NewRedisService(false), rates, config, registry)
+	params := pkg.QueryParams{Origin: "SYD", Destination: "BKK", Adults: 1, Date: time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 1, 0)}
+
+	response, err := retrieve(context.Background(), params)
+	run("First search hits vendors", func(t *testing.T) {
+		assert.NoError(t, err)
+		assert.Len(t, response.Cheapest, 2)
+		assert.Equal(t, int32(1), searches.Load())
+		assert.Equal(t, pkg.CacheMiss, response.Vendors[0].Cache)
+	})
+
+	run("Filters are applied on the cached vendor offers", func(t *testing.T) {
+		filtered := params
+		filtered.MaxPrice = 500
+		filtered.IncludedAirlines = []string{"TG"}
+		filtered.DepartureTimePreference = pkg.DepartureTimeMorning
+
+		response, err := retrieve(context.Background(), filtered)
+		assert.NoError(t, err)
+		assert.Len(t, response.Cheapest, 1)
+		assert.Equal(t, 300.0, response.Cheapest[0].Price.Value)
+		assert.Equal(t, 900.0, response.FilterStats.Price.Max.Value)
+		assert.Equal(t, pkg.CacheHit, response.Vendors[0].Cache)
+		assert.Equal(t, int32(1), searches.Load())
+	})
+
+	run("Vendor criteria still hit vendors", func(t *testing.T) {
+		other := params
+		other.Adults = 2
+
+		_, err := retrieve(context.Background(), other)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), searches.Load())
+	})
+}
+
+func TestRetrieveBestFlightsCacheSkippedVendors(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	t.Setenv("REDIS_URL", miniredis.RunT(t).Addr())
+	rates, err := exchange.DefaultFileProvider()()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	searches := &atomic.Int32{}
+	metered := &atomic.Int32{}
+	registry := providers.NewRegistry(countingProvider{searches: searches}, meteredProvider{searches: metered})
+	config := workflow.Config{
+		RankingWeights: mapping.DefaultRankingWeights,
+		VendorPolicy:   workflow.VendorPolicy{Mode: workflow.VendorPolicyFailAll},
+		VendorQuotas:   map[string]workflow.VendorQuota{pkg.SourceAmadeus: {PerDay: 1}},
+	}
+	retrieve := workflow.RetrieveBestFlights(redis.NewRedisService(false), rates, config, registry)
+	params := pkg.QueryParams{Origin: "SYD", Destination: "BKK", Adults: 1, Date: time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 1, 0)}
+
+	run("First search spends the quota", func(t *testing.T) {
+		response, err := retrieve(context.Background(), params)
+		assert.NoError(t, err)
+		assert.Len(t, response.Cheapest, 3)
+		assert.Equal(t, int32(1), metered.Load())
+	})
+
+	other := params
+	other.Adults = 2
+
+	run("Vendors out of quota are skipped instead of failing the search", func(t *testing.T) {
+		response, err := retrieve(context.Background(), other)
+		assert.NoError(t, err)
+		assert.Len(t, response.Cheapest, 2)
+		assert.Equal(t, int32(1), metered.Load())
+		assert.Equal(t, int32(2), searches.Load())
+		assert.Equal(t, pkg.SourceAmadeus, response.Vendors[0].Source)
+		assert.Empty(t, response.Vendors[0].Error)
+		assert.Contains(t, response.Vendors[0].Skipped, "quota exhausted")
+		assert.Len(t, response.Warnings, 1)
+	})
+
+	run("Searches with skipped vendors are cached", func(t *testing.T) {
+		response, err := retrieve(context.Background(), other)
+		assert.NoError(t, err)
+		assert.Len(t, response.Cheapest, 2)
+		assert.Equal(t, int32(1), metered.Load())
+		assert.Equal(t, int32(2), searches.Load())
+		assert.Equal(t, pkg.CacheHit, response.Vendors[1].Cache)
+	})
+}
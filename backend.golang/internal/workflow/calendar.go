@@ -0,0 +1,131 @@
+package workflow
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/exchange"
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/providers"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/redis"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+type RetrieveCalendarFunc func(ctx context.Context, params pkg.CalendarQueryParams) (pkg.GetCalendarResponse, error)
+
+// RetrieveCalendar builds the price calendar for a month, combining the flight dates of every provider quoting them
+// with the fares recorded from previous searches
+func RetrieveCalendar(redisClient redis.Service, rates exchange.RateProvider, registry providers.Registry) RetrieveCalendarFunc {
+	return func(ctx context.Context, params pkg.CalendarQueryParams) (pkg.GetCalendarResponse, error) {
+		var (
+			errors = make(chan error)
+			wg     sync.WaitGroup
+			mu     sync.Mutex
+			fares  = []pkg.CalendarDay{}
+			today  = time.Now().UTC().Truncate(24 * time.Hour)
+		)
+
+		firstDay, err := params.FirstDay()
+		if err != nil {
+			return pkg.GetCalendarResponse{}, err
+		}
+
+		lastDay := firstDay.AddDate(0, 1, -1)
+		from := firstDay
+		if from.Before(today) {
+			from = today
+		}
+
+		retrieveFareRequests := []func(channel chan error){
+			func(channel chan error) {
+				defer wg.Done()
+				recorded, err := redisClient.GetCalendarFares(ctx, params.Origin, params.Destination, firstDay)
+				if err != nil {
+					channel <- err
+					return
+				}
+
+				mu.Lock()
+				fares = append(fares, recorded...)
+				mu.Unlock()
+				log.Printf("found %v recorded calendar fares", len(recorded))
+			},
+		}
+
+		for _, provider := range registry.Providers() {
+			datesProvider, ok := provider.(providers.FlightDatesProvider)
+			// vendors do not price departures in the past
+			if !ok || from.After(lastDay) {
+				continue
+			}
+
+			retrieveFareRequests = append(retrieveFareRequests, func(channel chan error) {
+				defer wg.Done()
+				days, err := datesProvider.RetrieveFlightDates(ctx, params.Origin, params.Destination, from, lastDay)
+				if err != nil {
+					// flight dates are usually built from the vendor own cache, which does not cover every route,
+					// so we rely on the rest of fares instead of failing the whole calendar
+					log.Printf("unable to retrieve calendar fares from %s, error: %s", provider.Source(), err)
+					return
+				}
+
+				mu.Lock()
+				fares = append(fares, days...)
+				mu.Unlock()
+				log.Printf("found %v calendar fares with %s", len(days), provider.Source())
+			})
+		}
+
+		wg.Add(len(retrieveFareRequests))
+		go func() {
+			wg.Wait()
+			close(errors)
+		}()
+
+		for _, f := range retrieveFareRequests {
+			go f(errors)
+		}
+
+		// errors is only closed once every request is done, so the first error is never mistaken for a finished calendar
+		if err, ok := <-errors; ok {
+			return pkg.GetCalendarResponse{}, err
+		}
+
+		// fares are recorded in the currency each vendor priced them, so they are converted before picking the lowest one
+		fares, err = mapping.ConvertCalendarDays(rates, params.CurrencyCode(), fares...)
+		if err != nil {
+			return pkg.GetCalendarResponse{}, err
+		}
+
+		return mapping.NewCalendarResponse(params, firstDay, fares...), nil
+	}
+}
+
+// recordCalendarFares keeps the lowest fare per vendor found on a search, so the price calendar is able to use it later
+// Only searches comparable to a calendar fare are recorded, which is a one way economy ticket for a single adult
+func recordCalendarFares(ctx context.Context, redisClient redis.Service, params pkg.QueryParams, fares ...pkg.CalendarDay) {
+	if params.IsMultiCity() || params.IsRoundTrip() || params.Travelers() != 1 || params.Adults != 1 {
+		return
+	}
+
+	if params.Cabin != "" && params.Cabin != pkg.CabinEconomy {
+		return
+	}
+
+	// searches made of several airport pairs find several fares per vendor, only the lowest one is kept
+	lowest := map[string]pkg.CalendarDay{}
+	for _, fare := range fares {
+		if current, ok := lowest[fare.Source]; !ok || fare.Price.Value < current.Price.Value {
+			lowest[fare.Source] = fare
+		}
+	}
+
+	for _, fare := range lowest {
+		// the calendar is a nice to have, so a failure here should not fail the search itself
+		if err := redisClient.RecordCalendarFare(ctx, params.Origin, params.Destination, fare); err != nil {
+			log.Printf("unable to record calendar fare, error: %s", err)
+		}
+	}
+}
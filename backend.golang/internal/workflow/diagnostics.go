@@ -0,0 +1,39 @@
+package workflow
+
+import (
+	"context"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/providers"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/redis"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+type RetrieveVendorDiagnosticsFunc func(ctx context.Context) (pkg.GetVendorDiagnosticsResponse, error)
+
+// RetrieveVendorDiagnostics reports the health of every vendor registered
+func RetrieveVendorDiagnostics(redisClient redis.Service, config Config, registry providers.Registry) RetrieveVendorDiagnosticsFunc {
+	return func(ctx context.Context) (pkg.GetVendorDiagnosticsResponse, error) {
+		now := time.Now()
+		response := pkg.GetVendorDiagnosticsResponse{Vendors: []pkg.VendorDiagnostics{}}
+		for _, provider := range registry.Providers() {
+			diagnostics := pkg.VendorDiagnostics{Source: provider.Source(), Breaker: pkg.BreakerStatus{State: pkg.BreakerClosed}}
+			if config.Breakers != nil {
+				diagnostics.Breaker = config.Breakers.Breaker(provider.Source()).Status()
+			}
+
+			if quota, ok := config.VendorQuotas[provider.Source()]; ok {
+				quotas, err := quotaStatuses(ctx, redisClient, provider.Source(), quota, now)
+				if err != nil {
+					return pkg.GetVendorDiagnosticsResponse{}, err
+				}
+
+				diagnostics.Quotas = quotas
+			}
+
+			response.Vendors = append(response.Vendors, diagnostics)
+		}
+
+		return response, nil
+	}
+}
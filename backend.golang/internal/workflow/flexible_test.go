@@ -0,0 +1,142 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+// newStubDayOffer is an offer found on a single day, later days are cheaper yet slower
+func newStubDayOffer(day time.Time, price, duration float64) pkg.FlightOffer {
+	return pkg.FlightOffer{
+		Itinerary: pkg.Itinerary{
+			FlightNumber:      day.Format("0102"),
+			DurationInMinutes: duration,
+			Departure:         pkg.Location{Timestamp: day},
+		},
+		Price:  pkg.Amount{Value: price, Currency: pkg.DefaultCurrency},
+		Source: pkg.SourceAmadeus,
+	}
+}
+
+// stubRetrieveDay records every day searched, answering with a single offer per day
+type stubRetrieveDay struct {
+	mu       sync.Mutex
+	searched []pkg.QueryParams
+	failOn   time.Time
+}
+
+func (s *stubRetrieveDay) retrieve(ctx context.Context, params pkg.QueryParams) (pkg.GetBestFlightOffersResponse, error) {
+	s.mu.Lock()
+	s.searched = append(s.searched, params)
+	s.mu.Unlock()
+
+	if params.Date.Equal(s.failOn) {
+		return pkg.GetBestFlightOffersResponse{}, errors.New("amadeus - unexpected status 500")
+	}
+
+	if !s.failOn.IsZero() {
+		// the rest of days wait until the failure cancels them
+		<-ctx.Done()
+		return pkg.GetBestFlightOffersResponse{}, ctx.Err()
+	}
+
+	offset := params.Date.Sub(params.Date.Truncate(30*24*time.Hour)).Hours() / 24
+	offer := newStubDayOffer(params.Date, 500-offset*10, 600+offset*10)
+	response := mapping.NewBestFlightsOffersResponse(offer)
+	response.Vendors = []pkg.VendorReport{{Source: pkg.SourceAmadeus, Offers: 1, Cache: pkg.CacheMiss}}
+	return response, nil
+}
+
+func TestRetrieveFlexibleBestFlights(t *testing.T) {
+	run := testhelpers.Run(t)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	run("Days in the past are not searched", func(t *testing.T) {
+		stub := &stubRetrieveDay{}
+		params := pkg.QueryParams{Origin: "SYD", Destination: "BKK", Adults: 1, Date: tomorrow, FlexDays: 2}
+
+		response, err := retrieveFlexibleBestFlights(context.Background(), stub.retrieve, mapping.DefaultRankingWeights, params)
+		assert.NoError(t, err)
+		assert.Len(t, stub.searched, 4)
+		for _, day := range stub.searched {
+			assert.False(t, day.Date.Before(today), day.Date)
+			assert.Zero(t, day.FlexDays)
+		}
+
+		dates := []time.Time{}
+		for _, day := range response.Days {
+			dates = append(dates, day.Date)
+		}
+		assert.Equal(t, []time.Time{today, tomorrow, tomorrow.AddDate(0, 0, 1), tomorrow.AddDate(0, 0, 2)}, dates)
+	})
+
+	run("The requested date is searched even in the past", func(t *testing.T) {
+		stub := &stubRetrieveDay{}
+		yesterday := today.AddDate(0, 0, -1)
+		params := pkg.QueryParams{Origin: "SYD", Destination: "BKK", Adults: 1, Date: yesterday, FlexDays: 1}
+
+		response, err := retrieveFlexibleBestFlights(context.Background(), stub.retrieve, mapping.DefaultRankingWeights, params)
+		assert.NoError(t, err)
+		assert.Len(t, stub.searched, 2)
+		assert.Equal(t, yesterday, response.Days[0].Date)
+		assert.Equal(t, today, response.Days[1].Date)
+	})
+
+	run("Round trips keep the same trip length", func(t *testing.T) {
+		stub := &stubRetrieveDay{}
+		params := pkg.QueryParams{Origin: "SYD", Destination: "BKK", Adults: 1, Date: tomorrow, ReturnDate: tomorrow.AddDate(0, 0, 7), FlexDays: 1}
+
+		_, err := retrieveFlexibleBestFlights(context.Background(), stub.retrieve, mapping.DefaultRankingWeights, params)
+		assert.NoError(t, err)
+		assert.Len(t, stub.searched, 3)
+		for _, day := range stub.searched {
+			assert.Equal(t, day.Date.AddDate(0, 0, 7), day.ReturnDate)
+		}
+	})
+
+	run("Every day is reported along with the overall best offers", func(t *testing.T) {
+		stub := &stubRetrieveDay{}
+		params := pkg.QueryParams{Origin: "SYD", Destination: "BKK", Adults: 1, Date: tomorrow.AddDate(0, 0, 1), FlexDays: 1}
+
+		response, err := retrieveFlexibleBestFlights(context.Background(), stub.retrieve, mapping.DefaultRankingWeights, params)
+		assert.NoError(t, err)
+		assert.Len(t, response.Days, 3)
+		for _, day := range response.Days {
+			assert.Equal(t, day.Date, day.Cheapest.Departure.Timestamp)
+			assert.Equal(t, day.Date, day.Fastest.Departure.Timestamp)
+		}
+
+		// later days are cheaper and earlier days faster
+		assert.Len(t, response.Cheapest, 3)
+		assert.Equal(t, response.Days[2].Cheapest.FlightNumber, response.Cheapest[0].FlightNumber)
+		assert.Equal(t, response.Days[0].Fastest.FlightNumber, response.Fastest[0].FlightNumber)
+		assert.Len(t, response.Best, 3)
+		assert.Equal(t, []pkg.VendorReport{{Source: pkg.SourceAmadeus, Offers: 3, Cache: pkg.CacheMiss}}, response.Vendors)
+		assert.Empty(t, response.Warnings)
+	})
+
+	run("A day failing fails the search without leaking the rest of days", func(t *testing.T) {
+		before := runtime.NumGoroutine()
+		stub := &stubRetrieveDay{failOn: tomorrow.AddDate(0, 0, 1)}
+		params := pkg.QueryParams{Origin: "SYD", Destination: "BKK", Adults: 1, Date: tomorrow.AddDate(0, 0, 1), FlexDays: 1}
+
+		_, err := retrieveFlexibleBestFlights(context.Background(), stub.retrieve, mapping.DefaultRankingWeights, params)
+		assert.EqualError(t, err, "amadeus - unexpected status 500")
+
+		// the rest of days are cancelled, so every goroutine of the search finishes shortly after
+		for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > before && time.Now().Before(deadline); {
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+	})
+}
@@ -0,0 +1,107 @@
+package workflow
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/redis"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor can not be decoded or its results snapshot expired
+var ErrInvalidCursor = errors.New("invalid or expired cursor")
+
+// paginateBestFlights limits the results of a search, the first page stores a snapshot of the whole response
+// so the following pages are taken from the very same results, even after the cached search expires
+func paginateBestFlights(redisClient redis.Service, retrieve RetrieveBestFlightsFunc) RetrieveBestFlightsFunc {
+	return func(ctx context.Context, params pkg.QueryParams) (pkg.GetBestFlightOffersResponse, error) {
+		if params.Limit == 0 {
+			return retrieve(ctx, params)
+		}
+
+		if params.Cursor != "" {
+			return nextBestFlightsPage(ctx, redisClient, params)
+		}
+
+		response, err := retrieve(ctx, params)
+		if err != nil {
+			return pkg.GetBestFlightOffersResponse{}, err
+		}
+
+		snapshotID, err := newSnapshotID()
+		if err != nil {
+			return pkg.GetBestFlightOffersResponse{}, err
+		}
+
+		if err := redisClient.StoreBestFlightSnapshot(ctx, snapshotID, response); err != nil {
+			return pkg.GetBestFlightOffersResponse{}, err
+		}
+
+		return newBestFlightsPage(snapshotID, response, 0, params.Limit), nil
+	}
+}
+
+func nextBestFlightsPage(ctx context.Context, redisClient redis.Service, params pkg.QueryParams) (pkg.GetBestFlightOffersResponse, error) {
+	snapshotID, offset, err := decodeCursor(params.Cursor)
+	if err != nil {
+		return pkg.GetBestFlightOffersResponse{}, err
+	}
+
+	snapshot, err := redisClient.GetBestFlightSnapshot(ctx, snapshotID)
+	if err != nil {
+		return pkg.GetBestFlightOffersResponse{}, err
+	}
+
+	if snapshot == nil {
+		return pkg.GetBestFlightOffersResponse{}, ErrInvalidCursor
+	}
+
+	return newBestFlightsPage(snapshotID, *snapshot, offset, params.Limit), nil
+}
+
+func newBestFlightsPage(snapshotID string, response pkg.GetBestFlightOffersResponse, offset, limit int) pkg.GetBestFlightOffersResponse {
+	page := mapping.NewBestFlightsOffersPage(response, offset, limit)
+	if offset+limit < page.Pagination.Total {
+		page.Pagination.NextCursor = encodeCursor(snapshotID, offset+limit)
+	}
+
+	return page
+}
+
+func newSnapshotID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "workflow - failed to generate snapshot id")
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func encodeCursor(snapshotID string, offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(snapshotID + ":" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (string, int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, ErrInvalidCursor
+	}
+
+	snapshotID, rawOffset, ok := strings.Cut(string(b), ":")
+	if !ok || snapshotID == "" {
+		return "", 0, ErrInvalidCursor
+	}
+
+	offset, err := strconv.Atoi(rawOffset)
+	if err != nil || offset < 0 {
+		return "", 0, ErrInvalidCursor
+	}
+
+	return snapshotID, offset, nil
+}
@@ -0,0 +1,108 @@
+package workflow
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/redis"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginateBestFlights(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	server := miniredis.RunT(t)
+	t.Setenv("REDIS_URL", server.Addr())
+
+	// every search finds different prices, so a page taken from a new search would not match the first one
+	searches := 0
+	retrieve := paginateBestFlights(redis.NewRedisService(false), func(ctx context.Context, params pkg.QueryParams) (pkg.GetBestFlightOffersResponse, error) {
+		searches++
+		offers := []pkg.FlightOffer{}
+		for i := range 5 {
+			offers = append(offers, pkg.FlightOffer{
+				Itinerary: pkg.Itinerary{FlightNumber: "TG " + strconv.Itoa(i)},
+				Price:     pkg.Amount{Value: float64(100*searches + i), Currency: pkg.DefaultCurrency},
+			})
+		}
+
+		return pkg.GetBestFlightOffersResponse{Cheapest: offers, Fastest: offers}, nil
+	})
+
+	params := pkg.QueryParams{Origin: "SYD", Destination: "BKK", Limit: 2}
+
+	first, err := retrieve(context.Background(), params)
+	run("First page stores a snapshot", func(t *testing.T) {
+		assert.NoError(t, err)
+		assert.Equal(t, 1, searches)
+		assert.Len(t, first.Cheapest, 2)
+		assert.Equal(t, 100.0, first.Cheapest[0].Price.Value)
+		assert.Equal(t, pkg.Pagination{Limit: 2, Offset: 0, Total: 5, NextCursor: first.Pagination.NextCursor}, *first.Pagination)
+		assert.NotEmpty(t, first.Pagination.NextCursor)
+	})
+
+	// the cached search entry only lives for 30 seconds, the snapshot outlives it
+	server.FastForward(time.Minute)
+
+	run("Following pages are taken from the snapshot", func(t *testing.T) {
+		next := params
+		next.Cursor = first.Pagination.NextCursor
+
+		second, err := retrieve(context.Background(), next)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, searches)
+		assert.Equal(t, 2, second.Pagination.Offset)
+		assert.Equal(t, []float64{102, 103}, []float64{second.Cheapest[0].Price.Value, second.Cheapest[1].Price.Value})
+		assert.NotEmpty(t, second.Pagination.NextCursor)
+
+		next.Cursor = second.Pagination.NextCursor
+		third, err := retrieve(context.Background(), next)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, searches)
+		assert.Equal(t, 4, third.Pagination.Offset)
+		assert.Len(t, third.Cheapest, 1)
+		assert.Equal(t, 104.0, third.Cheapest[0].Price.Value)
+		assert.Empty(t, third.Pagination.NextCursor)
+	})
+
+	run("Malformed cursors are rejected", func(t *testing.T) {
+		snapshotID, _, err := decodeCursor(first.Pagination.NextCursor)
+		assert.NoError(t, err)
+
+		for _, cursor := range []string{
+			"not base64!",
+			base64.RawURLEncoding.EncodeToString([]byte("no-offset")),
+			base64.RawURLEncoding.EncodeToString([]byte(":2")),
+			base64.RawURLEncoding.EncodeToString([]byte(snapshotID + ":two")),
+			base64.RawURLEncoding.EncodeToString([]byte(snapshotID + ":-2")),
+		} {
+			next := params
+			next.Cursor = cursor
+
+			_, err := retrieve(context.Background(), next)
+			assert.ErrorIs(t, err, ErrInvalidCursor, cursor)
+		}
+		assert.Equal(t, 1, searches)
+	})
+
+	run("Missing snapshots are rejected", func(t *testing.T) {
+		next := params
+		next.Cursor = encodeCursor("unknown", 2)
+
+		_, err := retrieve(context.Background(), next)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+
+		server.FastForward(30 * time.Minute)
+		next.Cursor = first.Pagination.NextCursor
+
+		_, err = retrieve(context.Background(), next)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+		assert.Equal(t, 1, searches)
+	})
+}
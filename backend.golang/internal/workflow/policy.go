@@ -0,0 +1,72 @@
+package workflow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// Policies deciding whether a search with failed vendors still returns the offers found by the rest of them
+const (
+	VendorPolicyFailAll    = "FAIL_ALL"    // any vendor failing fails the whole search
+	VendorPolicyBestEffort = "BEST_EFFORT" // the search fails only when every vendor failed
+	VendorPolicyRequire    = "REQUIRE"     // the search fails unless a minimum number of vendors succeeded, e.g REQUIRE_2
+)
+
+// DefaultVendorPolicy keeps searching as long as a single vendor is up
+var DefaultVendorPolicy = VendorPolicy{Mode: VendorPolicyBestEffort}
+
+// VendorPolicy represents how many vendors are required to succeed on a search
+type VendorPolicy struct {
+	Mode       string
+	MinVendors int // only used by the REQUIRE policy
+}
+
+// ParseVendorPolicy parses a policy as FAIL_ALL, BEST_EFFORT or REQUIRE_N, N being the minimum number of vendors
+func ParseVendorPolicy(value string) (VendorPolicy, error) {
+	switch value {
+	case VendorPolicyFailAll, VendorPolicyBestEffort:
+		return VendorPolicy{Mode: value}, nil
+	}
+
+	minVendors, err := strconv.Atoi(strings.TrimPrefix(value, VendorPolicyRequire+"_"))
+	if !strings.HasPrefix(value, VendorPolicyRequire+"_") || err != nil || minVendors < 1 {
+		return VendorPolicy{}, fmt.Errorf("workflow - vendor policy should be one of FAIL_ALL, BEST_EFFORT or REQUIRE_N, got %q", value)
+	}
+
+	return VendorPolicy{Mode: VendorPolicyRequire, MinVendors: minVendors}, nil
+}
+
+// Check fails a search when not enough vendors succeeded, a vendor succeeds when none of its requests failed
+// Vendors not searched at all, e.g the ones unable to price multi-city itineraries or skipped for being down or
+// out of quota, are not required
+func (p VendorPolicy) Check(reports ...pkg.VendorReport) error {
+	searched := 0
+	failed := []string{}
+	for _, report := range reports {
+		if report.Error != "" {
+			failed = append(failed, fmt.Sprintf("%s: %s", report.Source, report.Error))
+		}
+
+		if report.Error != "" || report.Skipped == "" {
+			searched++
+		}
+	}
+
+	succeeded := searched - len(failed)
+	required := searched
+	switch p.Mode {
+	case VendorPolicyBestEffort:
+		required = min(1, searched)
+	case VendorPolicyRequire:
+		required = min(p.MinVendors, searched)
+	}
+
+	if succeeded < required {
+		return fmt.Errorf("workflow - %d vendors succeeded while %d are required, errors: %s", succeeded, required, strings.Join(failed, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package workflow_test
+
+import (
+	"testing"
+
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/internal/workflow"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVendorPolicy(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	run("Named policies", func(t *testing.T) {
+		policy, err := workflow.ParseVendorPolicy("FAIL_ALL")
+		assert.NoError(t, err)
+		assert.Equal(t, workflow.VendorPolicy{Mode: workflow.VendorPolicyFailAll}, policy)
+	})
+
+	run("Minimum number of vendors", func(t *testing.T) {
+		policy, err := workflow.ParseVendorPolicy("REQUIRE_2")
+		assert.NoError(t, err)
+		assert.Equal(t, workflow.VendorPolicy{Mode: workflow.VendorPolicyRequire, MinVendors: 2}, policy)
+	})
+
+	run("Invalid policies", func(t *testing.T) {
+		for _, value := range []string{"ALL", "REQUIRE", "REQUIRE_0", "REQUIRE_TWO"} {
+			_, err := workflow.ParseVendorPolicy(value)
+			assert.Error(t, err, value)
+		}
+	})
+}
+
+func TestVendorPolicyCheck(t *testing.T) {
+	reports := []pkg.VendorReport{
+		{Source: pkg.SourceAmadeus, Offers: 10},
+		{Source: pkg.SourceFlightsky, Error: "flightsky - unexpected status 429"},
+		{Source: pkg.SourceGoogleflights, Offers: 5},
+	}
+
+	failed := []pkg.VendorReport{
+		{Source: pkg.SourceAmadeus, Error: "amadeus - unexpected status 500"},
+		{Source: pkg.SourceFlightsky, Error: "flightsky - unexpected status 429"},
+	}
+
+	run := testhelpers.Run(t)
+
+	run("Fail all", func(t *testing.T) {
+		policy := workflow.VendorPolicy{Mode: workflow.VendorPolicyFailAll}
+		assert.Error(t, policy.Check(reports...))
+		assert.NoError(t, policy.Check(reports[0], reports[2]))
+	})
+
+	run("Best effort", func(t *testing.T) {
+		policy := workflow.VendorPolicy{Mode: workflow.VendorPolicyBestEffort}
+		assert.NoError(t, policy.Check(reports...))
+		assert.Error(t, policy.Check(failed...))
+	})
+
+	run("Require N vendors", func(t *testing.T) {
+		assert.NoError(t, workflow.VendorPolicy{Mode: workflow.VendorPolicyRequire, MinVendors: 2}.Check(reports...))
+		assert.Error(t, workflow.VendorPolicy{Mode: workflow.VendorPolicyRequire, MinVendors: 3}.Check(reports...))
+	})
+
+	run("Vendors not searched are not required", func(t *testing.T) {
+		policy := workflow.VendorPolicy{Mode: workflow.VendorPolicyRequire, MinVendors: 3}
+		assert.NoError(t, policy.Check(reports[0]))
+	})
+
+	run("Skipped vendors are not required", func(t *testing.T) {
+		skipped := pkg.VendorReport{Source: pkg.SourceFlightsky, Skipped: "flightsky: day quota exhausted"}
+		assert.NoError(t, workflow.VendorPolicy{Mode: workflow.VendorPolicyFailAll}.Check(reports[0], skipped))
+		assert.NoError(t, workflow.VendorPolicy{Mode: workflow.VendorPolicyRequire, MinVendors: 2}.Check(reports[0], skipped))
+	})
+}
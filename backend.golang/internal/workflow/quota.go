@@ -0,0 +1,176 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/redis"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+)
+
+// ErrQuotaExhausted is reported for vendors skipped because their calls budget is spent
+var ErrQuotaExhausted = errors.New("quota exhausted")
+
+// VendorQuota limits the http calls sent to a vendor, zero means unlimited
+type VendorQuota struct {
+	PerSecond int64
+	PerDay    int64
+	PerMonth  int64
+}
+
+// quotaWindow is a single window of a vendor quota, counted on its own redis key
+type quotaWindow struct {
+	name    string
+	limit   int64
+	key     string
+	resetAt time.Time
+}
+
+// ParseVendorQuotas parses per vendor quotas as comma separated vendor=limits pairs, limits are colon separated
+// calls per window, e.g flightsky=5/s:1000/day:20000/month,googleflights=100/day
+func ParseVendorQuotas(value string) (map[string]VendorQuota, error) {
+	quotas := map[string]VendorQuota{}
+	for _, pair := range strings.Split(value, ",") {
+		source, rawLimits, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("workflow - vendor quotas should be formatted as vendor=limits, got %q", pair)
+		}
+
+		quota := VendorQuota{}
+		for _, rawLimit := range strings.Split(rawLimits, ":") {
+			rawCalls, window, _ := strings.Cut(rawLimit, "/")
+			calls, err := strconv.ParseInt(rawCalls, 10, 64)
+			if err != nil || calls <= 0 {
+				return nil, fmt.Errorf("workflow - vendor quota for %s should be a positive number of calls, got %q", source, rawLimit)
+			}
+
+			switch window {
+			case "s":
+				quota.PerSecond = calls
+			case "day":
+				quota.PerDay = calls
+			case "month":
+				quota.PerMonth = calls
+			default:
+				return nil, fmt.Errorf("workflow - vendor quota for %s should be per s, day or month, got %q", source, rawLimit)
+			}
+		}
+
+		quotas[source] = quota
+	}
+
+	return quotas, nil
+}
+
+// windows lists the limited windows of the quota at the given time, every window starts at the beginning of its
+// second, day or month in UTC
+func (q VendorQuota) windows(source string, now time.Time) []quotaWindow {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	windows := []quotaWindow{}
+	if q.PerSecond > 0 {
+		windows = append(windows, quotaWindow{
+			name:    pkg.QuotaWindowSecond,
+			limit:   q.PerSecond,
+			key:     fmt.Sprintf("quota:%s:second:%d", source, now.Unix()),
+			resetAt: now.Truncate(time.Second).Add(time.Second),
+		})
+	}
+
+	if q.PerDay > 0 {
+		windows = append(windows, quotaWindow{
+			name:    pkg.QuotaWindowDay,
+			limit:   q.PerDay,
+			key:     fmt.Sprintf("quota:%s:day:%s", source, day.Format("2006-01-02")),
+			resetAt: day.AddDate(0, 0, 1),
+		})
+	}
+
+	if q.PerMonth > 0 {
+		windows = append(windows, quotaWindow{
+			name:    pkg.QuotaWindowMonth,
+			limit:   q.PerMonth,
+			key:     fmt.Sprintf("quota:%s:month:%s", source, month.Format("2006-01")),
+			resetAt: month.AddDate(0, 1, 0),
+		})
+	}
+
+	return windows
+}
+
+// limitVendorRequest counts every http call the request sends to its vendor, as vendors bill retries and follow up
+// lookups too, calls are stopped once any window of the quota of the vendor is exhausted
+func limitVendorRequest(redisClient redis.Service, quotas map[string]VendorQuota, request vendorRequest) vendorRequest {
+	quota := quotas[request.source]
+	if quota == (VendorQuota{}) {
+		return request
+	}
+
+	search := request.search
+	request.search = func(ctx context.Context) ([]pkg.FlightOffer, []pkg.CalendarDay, error) {
+		return search(vendors.WithCallHook(ctx, func(ctx context.Context) error {
+			return consumeQuota(ctx, redisClient, request.source, quota)
+		}))
+	}
+
+	return request
+}
+
+// consumeQuota counts a single call on every window of the quota
+// Quotas are not enforced while redis is unavailable, a broken cache should not stop searches
+func consumeQuota(ctx context.Context, redisClient redis.Service, source string, quota VendorQuota) error {
+	now := time.Now()
+	windows := quota.windows(source, now)
+	counters := []redis.QuotaCounter{}
+	for _, window := range windows {
+		// counters outlive their window a bit, so clocks slightly apart across instances share them
+		counters = append(counters, redis.QuotaCounter{Key: window.key, Limit: window.limit, TTL: window.resetAt.Sub(now) + time.Minute})
+	}
+
+	exhausted, err := redisClient.ConsumeQuota(ctx, counters...)
+	if err != nil {
+		log.Printf("unable to check %s quota, calling anyway, error: %s", source, err)
+		return nil
+	}
+
+	if exhausted >= 0 {
+		return fmt.Errorf("%s: %s %w", source, strings.ToLower(windows[exhausted].name), ErrQuotaExhausted)
+	}
+
+	return nil
+}
+
+// quotaStatuses reports the calls made to a vendor within the current window of its quota
+func quotaStatuses(ctx context.Context, redisClient redis.Service, source string, quota VendorQuota, now time.Time) ([]pkg.QuotaStatus, error) {
+	windows := quota.windows(source, now)
+	keys := []string{}
+	for _, window := range windows {
+		keys = append(keys, window.key)
+	}
+
+	usage, err := redisClient.GetQuotaUsage(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []pkg.QuotaStatus{}
+	for i, window := range windows {
+		statuses = append(statuses, pkg.QuotaStatus{
+			Window:    window.name,
+			Limit:     window.limit,
+			Used:      usage[i],
+			Remaining: max(window.limit-usage[i], 0),
+			ResetAt:   window.resetAt,
+		})
+	}
+
+	return statuses, nil
+}
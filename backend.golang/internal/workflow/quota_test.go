@@ -0,0 +1,141 @@
+package workflow
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/redis"
+	"github.com/rubengp99/golang-flights-challenge/internal/vendors/testhelpers"
+	"github.com/rubengp99/golang-flights-challenge/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVendorQuotas(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	run("Limits per window", func(t *testing.T) {
+		quotas, err := ParseVendorQuotas("flightsky=5/s:1000/day:20000/month,googleflights=100/day")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]VendorQuota{
+			pkg.SourceFlightsky:     {PerSecond: 5, PerDay: 1000, PerMonth: 20000},
+			pkg.SourceGoogleflights: {PerDay: 100},
+		}, quotas)
+	})
+
+	run("Invalid quotas", func(t *testing.T) {
+		for _, value := range []string{"flightsky", "flightsky=5", "flightsky=0/day", "flightsky=5/week", "flightsky=five/s"} {
+			_, err := ParseVendorQuotas(value)
+			assert.Error(t, err, value)
+		}
+	})
+}
+
+func TestVendorQuotaWindows(t *testing.T) {
+	now := time.Date(2025, 5, 31, 23, 59, 59, 500, time.UTC)
+	windows := VendorQuota{PerSecond: 5, PerDay: 1000, PerMonth: 20000}.windows(pkg.SourceFlightsky, now)
+
+	assert.Equal(t, []quotaWindow{
+		{name: pkg.QuotaWindowSecond, limit: 5, key: "quota:flightsky:second:1748735999", resetAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{name: pkg.QuotaWindowDay, limit: 1000, key: "quota:flightsky:day:2025-05-31", resetAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{name: pkg.QuotaWindowMonth, limit: 20000, key: "quota:flightsky:month:2025-05", resetAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+	}, windows)
+	assert.Empty(t, VendorQuota{}.windows(pkg.SourceFlightsky, now))
+}
+
+func TestLimitVendorRequest(t *testing.T) {
+	run := testhelpers.Run(t)
+	redisClient := redis.NewRedisService(true)
+	quotas := map[string]VendorQuota{pkg.SourceFlightsky: {PerDay: 1}}
+
+	run("Quotas are not enforced without redis", func(t *testing.T) {
+		request := limitVendorRequest(redisClient, quotas, newDelayedVendorRequest(pkg.SourceFlightsky, 0, 2))
+		for i := 0; i < 3; i++ {
+			offers, _, err := request.search(context.Background())
+			assert.NoError(t, err)
+			assert.Len(t, offers, 2)
+		}
+	})
+
+	run("Remaining quota is reported per window", func(t *testing.T) {
+		now := time.Date(2025, 5, 9, 10, 0, 0, 0, time.UTC)
+		statuses, err := quotaStatuses(context.Background(), redisClient, pkg.SourceFlightsky, quotas[pkg.SourceFlightsky], now)
+		assert.NoError(t, err)
+		assert.Equal(t, []pkg.QuotaStatus{
+			{Window: pkg.QuotaWindowDay, Limit: 1, Used: 0, Remaining: 1, ResetAt: time.Date(2025, 5, 10, 0, 0, 0, 0, time.UTC)},
+		}, statuses)
+	})
+}
+
+// retryingService is a vendor service retrying failed calls right away
+type retryingService struct{}
+
+func (s retryingService) Authenticate(req *http.Request) error {
+	return nil
+}
+
+func (s retryingService) Client() *http.Client {
+	return http.DefaultClient
+}
+
+func (s retryingService) RetryPolicy() vendors.RetryPolicy {
+	return vendors.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+}
+
+func TestLimitVendorRequestCalls(t *testing.T) {
+	run := testhelpers.Run(t)
+
+	t.Setenv("REDIS_URL", miniredis.RunT(t).Addr())
+	redisClient := redis.NewRedisService(false)
+	quota := VendorQuota{PerDay: 3}
+
+	// the first call fails and is retried, the rest succeed
+	calls := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	request := limitVendorRequest(redisClient, map[string]VendorQuota{pkg.SourceFlightsky: quota}, vendorRequest{
+		source: pkg.SourceFlightsky,
+		search: func(ctx context.Context) ([]pkg.FlightOffer, []pkg.CalendarDay, error) {
+			var response map[string]any
+			err := vendors.MakeHTTPRequest(ctx, retryingService{}, vendors.Request{BaseURL: server.URL, Resource: "flights", Method: http.MethodGet}, &response)
+			return nil, nil, err
+		},
+	})
+
+	used := func(t *testing.T) int64 {
+		statuses, err := quotaStatuses(context.Background(), redisClient, pkg.SourceFlightsky, quota, time.Now())
+		assert.NoError(t, err)
+		return statuses[0].Used
+	}
+
+	run("Retried calls are counted", func(t *testing.T) {
+		_, _, err := request.search(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), calls.Load())
+		assert.Equal(t, int64(2), used(t))
+	})
+
+	run("Calls are stopped once the quota is exhausted", func(t *testing.T) {
+		_, _, err := request.search(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), used(t))
+
+		_, _, err = request.search(context.Background())
+		assert.ErrorIs(t, err, ErrQuotaExhausted)
+		assert.Equal(t, int32(3), calls.Load())
+		assert.Equal(t, int64(3), used(t))
+	})
+}
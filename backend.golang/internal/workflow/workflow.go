@@ -1,91 +1,373 @@
 package workflow
 
 import (
+	"context"
 	"log"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/pkg/errors"
+	"github.com/rubengp99/golang-flights-challenge/internal/airports"
+	"github.com/rubengp99/golang-flights-challenge/internal/breaker"
+	"github.com/rubengp99/golang-flights-challenge/internal/exchange"
 	"github.com/rubengp99/golang-flights-challenge/internal/mapping"
-	"github.com/rubengp99/golang-flights-challenge/internal/vendors/amadeus"
-	"github.com/rubengp99/golang-flights-challenge/internal/vendors/flightsky"
-	"github.com/rubengp99/golang-flights-challenge/internal/vendors/googleflights"
+	"github.com/rubengp99/golang-flights-challenge/internal/providers"
 	"github.com/rubengp99/golang-flights-challenge/internal/vendors/redis"
 	"github.com/rubengp99/golang-flights-challenge/pkg"
 )
 
-type RetrieveBestFlightsFunc func(params pkg.QueryParams) (pkg.GetBestFlightOffersResponse, error)
+// Config represents how searches are aggregated across vendors
+type Config struct {
+	RankingWeights  pkg.RankingWeights
+	VendorPolicy    VendorPolicy
+	SearchBudget    time.Duration            // zero waits for every vendor
+	VendorDeadlines map[string]time.Duration // vendors missing here are waited for the whole search budget
+	Breakers        *breaker.Breakers        // nil searches every vendor regardless of its failures
+	VendorQuotas    map[string]VendorQuota   // vendors missing here are searched as much as needed
+}
+
+type RetrieveBestFlightsFunc func(ctx context.Context, params pkg.QueryParams) (pkg.GetBestFlightOffersResponse, error)
 
 func RetrieveBestFlights(redisClient redis.Service,
-	googleflightService googleflights.Service,
-	amadeusService amadeus.Service,
-	flightskyService flightsky.Service) RetrieveBestFlightsFunc {
-	return func(params pkg.QueryParams) (pkg.GetBestFlightOffersResponse, error) {
-		var (
-			errors       = make(chan error)
-			wgdone       = make(chan bool)
-			wg           sync.WaitGroup
-			flightOffers = []pkg.FlightOffer{}
-		)
-
-		id := params.Encode()
-		cachedResponse, err := redisClient.GetCachedBestFlightResponse(id)
-		if cachedResponse != nil {
-			return *cachedResponse, nil
+	rates exchange.RateProvider,
+	config Config,
+	registry providers.Registry) RetrieveBestFlightsFunc {
+	retrieveDay := retrieveBestFlights(redisClient, rates, config, registry)
+	return paginateBestFlights(redisClient, func(ctx context.Context, params pkg.QueryParams) (pkg.GetBestFlightOffersResponse, error) {
+		retrieve := retrieveDay
+		if params.FlexDays > 0 {
+			retrieve = func(ctx context.Context, params pkg.QueryParams) (pkg.GetBestFlightOffersResponse, error) {
+				return retrieveFlexibleBestFlights(ctx, retrieveDay, config.RankingWeights, params)
+			}
+		}
+
+		response, err := retrieve(ctx, params)
+		if err != nil {
+			return pkg.GetBestFlightOffersResponse{}, err
+		}
+
+		// the cheapest offers list every offer found, so any other order is sorted from there
+		if less, ok := mapping.SortOrders[params.Sort]; ok {
+			response.Sorted = mapping.SortFlightOffers(less, response.Cheapest...)
+		}
+
+		if params.Pareto != "" {
+			response.Pareto = mapping.ParetoFlightOffers(params.Pareto, response.Cheapest...)
+		}
+
+		return response, nil
+	})
+}
+
+// retrieveFlexibleBestFlights searches every departure date within the flexible window as a single day search
+// so each day is cached on its own, and overlapping windows do not hit vendors again
+func retrieveFlexibleBestFlights(ctx context.Context, retrieveDay RetrieveBestFlightsFunc, weights pkg.RankingWeights, params pkg.QueryParams) (pkg.GetBestFlightOffersResponse, error) {
+	// a single day failing fails the whole search, so the rest of days are cancelled
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg    sync.WaitGroup
+		today = time.Now().UTC().Truncate(24 * time.Hour)
+		days  = []pkg.QueryParams{}
+	)
+
+	for offset := -params.FlexDays; offset <= params.FlexDays; offset++ {
+		day := params
+		day.FlexDays = 0
+		day.Date = params.Date.AddDate(0, 0, offset)
+		// round trips keep the same trip length
+		if params.IsRoundTrip() {
+			day.ReturnDate = params.ReturnDate.AddDate(0, 0, offset)
+		}
+
+		// we cannot fly in the past, unless that was the date requested in the first place
+		if day.Date.Before(today) && day.Date.Before(params.Date) {
+			continue
+		}
+
+		days = append(days, day)
+	}
+
+	// every day is able to report its error without anybody listening, so none of them is left blocked
+	// once the search returns on the first error
+	errors := make(chan error, len(days))
+	responses := make([]pkg.GetBestFlightOffersResponse, len(days))
+	retrieveDayRequests := []func(channel chan error){}
+	for i, day := range days {
+		retrieveDayRequests = append(retrieveDayRequests, func(channel chan error) {
+			defer wg.Done()
+			response, err := retrieveDay(ctx, day)
+			if err != nil {
+				channel <- err
+				return
+			}
+			responses[i] = response
+		})
+	}
+
+	wg.Add(len(retrieveDayRequests))
+	go func() {
+		wg.Wait()
+		close(errors)
+	}()
+
+	for _, f := range retrieveDayRequests {
+		go f(errors)
+	}
+
+	// errors is only closed once every day is done, so the first error is never mistaken for a finished search
+	if err, ok := <-errors; ok {
+		return pkg.GetBestFlightOffersResponse{}, err
+	}
+
+	// cheapest holds every offer of the day, so we use it to build the overall response
+	flightOffers := []pkg.FlightOffer{}
+	dailyOffers := []pkg.DailyFlightOffers{}
+	stats := []pkg.FilterStats{}
+	reports := []pkg.VendorReport{}
+	for i, response := range responses {
+		if response.FilterStats != nil {
+			stats = append(stats, *response.FilterStats)
+		}
+
+		daily := pkg.DailyFlightOffers{Date: days[i].Date}
+		if len(response.Cheapest) > 0 {
+			daily.Cheapest = &response.Cheapest[0]
+		}
+
+		if len(response.Fastest) > 0 {
+			daily.Fastest = &response.Fastest[0]
+		}
+
+		flightOffers = append(flightOffers, response.Cheapest...)
+		dailyOffers = append(dailyOffers, daily)
+		reports = append(reports, response.Vendors...)
+	}
+
+	response := mapping.NewBestFlightsOffersResponse(flightOffers...)
+	response.Best = mapping.RankFlightOffers(params.RankingWeights(weights), params.DepartureTimePreference, flightOffers...)
+	filterStats := mapping.MergeFilterStats(stats...)
+	response.FilterStats = &filterStats
+	response.Days = dailyOffers
+	response.Vendors = mapping.MergeVendorReports(reports...)
+	response.Warnings = mapping.NewVendorWarnings(response.Vendors...)
+	return response, nil
+}
+
+// retrieveBestFlights searches flight offers for a single departure date across all vendors
+// Vendors failing are reported as warnings, the vendor policy decides whether the rest of offers are still returned
+// Vendors not answering within the search budget are left out, yet their late offers are cached for the next search
+func retrieveBestFlights(redisClient redis.Service,
+	rates exchange.RateProvider,
+	config Config,
+	registry providers.Registry) RetrieveBestFlightsFunc {
+	return func(ctx context.Context, params pkg.QueryParams) (pkg.GetBestFlightOffersResponse, error) {
+		retrievedAt := time.Now().UTC()
+		id := params.SearchKey()
+		cached, err := redisClient.GetCachedVendorOffers(ctx, id)
+		if cached != nil {
+			return newBestFlightsResponse(config, params, cached.Offers, mapping.NewCachedVendorReports(cached.Vendors...)), nil
 		}
 
 		if err != nil {
 			return pkg.GetBestFlightOffersResponse{}, err
 		}
 
-		// retrieve all secrets from infisical
-		retrieveFlightRequests := []func(channel chan error){
-			func(channel chan error) {
-				flights, err := googleflightService.RetrieveFlightOffers(params)
-				flightOffers = append(flightOffers, mapping.GoogleflightsToPkgFlights(errors, flights)...)
-				if err != nil {
-					channel <- err
-				}
-				log.Printf("found %v flights with google flights", len(flightOffers))
-				wg.Done()
-			},
-			func(channel chan error) {
-				flights, airlines, err := amadeusService.RetrieveFlightOffers(params)
-				flightOffers = append(flightOffers, mapping.AmadeusToPkgFlights(errors, flights, airlines)...)
-				if err != nil {
-					channel <- err
-				}
-				log.Printf("found %v flights with amadeus", len(flightOffers))
-				wg.Done()
-			},
-			func(channel chan error) {
-				flights, err := flightskyService.RetrieveFlightOffers(params)
-				flightOffers = append(flightOffers, mapping.FlightskyToPkgFlights(errors, flights)...)
-				if err != nil {
-					channel <- err
-				}
-				log.Printf("found %v flights with flightsky", len(flightOffers))
-				wg.Done()
-			},
-		}
-
-		wg.Add(len(retrieveFlightRequests))
-		go func() {
-			wg.Wait()
-			close(wgdone)
-			close(errors)
-		}()
-
-		for _, f := range retrieveFlightRequests {
-			go f(errors)
-		}
-
-		select {
-		case <-wgdone:
-			break
-		case err := <-errors:
+		requests := []vendorRequest{}
+		for _, provider := range registry.Providers() {
+			capabilities := provider.Capabilities()
+			if params.IsMultiCity() && !capabilities.MultiCity {
+				continue
+			}
+
+			for _, search := range providerSearches(capabilities, params) {
+				request := vendorRequest{source: provider.Source(), search: func(ctx context.Context) ([]pkg.FlightOffer, []pkg.CalendarDay, error) {
+					return searchProvider(ctx, provider, search, retrievedAt)
+				}}
+
+				// open circuits skip the vendor before its quota is spent
+				requests = append(requests, guardVendorRequest(config.Breakers, limitVendorRequest(redisClient, config.VendorQuotas, request)))
+			}
+		}
+
+		results, late, err := searchVendors(ctx, config, requests...)
+		if err != nil {
 			return pkg.GetBestFlightOffersResponse{}, err
 		}
 
-		response := mapping.NewBestFlightsOffersResponse(flightOffers...)
-		return response, redisClient.CacheBestFlightResponse(id, response)
+		if late != nil {
+			// the search is served by now, so late results are cached regardless of the request being done
+			go cacheLateVendorOffers(context.WithoutCancel(ctx), redisClient, rates, config, params, late)
+		}
+
+		vendorOffers, err := newVendorOffers(ctx, redisClient, rates, config, params, results...)
+		if err != nil {
+			return pkg.GetBestFlightOffersResponse{}, err
+		}
+
+		response := newBestFlightsResponse(config, params, vendorOffers.Offers, vendorOffers.Vendors)
+
+		// partial results are not cached, so the next search gives failed vendors another chance
+		// Skipped vendors are not given another chance, as they stay down or out of quota for a while
+		if failedVendors(vendorOffers.Vendors...) {
+			return response, nil
+		}
+
+		return response, redisClient.CacheVendorOffers(ctx, id, vendorOffers)
+	}
+}
+
+// cacheLateVendorOffers waits for the vendors that timed out on a search, caching all their offers for the next one
+func cacheLateVendorOffers(ctx context.Context, redisClient redis.Service, rates exchange.RateProvider, config Config, params pkg.QueryParams, late <-chan []vendorResult) {
+	vendorOffers, err := newVendorOffers(ctx, redisClient, rates, config, params, <-late...)
+	if err != nil {
+		log.Printf("unable to build late vendor offers, error: %s", err)
+		return
+	}
+
+	if failedVendors(vendorOffers.Vendors...) {
+		return
+	}
+
+	if err := redisClient.CacheVendorOffers(ctx, params.SearchKey(), vendorOffers); err != nil {
+		log.Printf("unable to cache late vendor offers, error: %s", err)
+	}
+}
+
+// newVendorOffers aggregates the results of every vendor request into a single list of offers in the requested currency
+func newVendorOffers(ctx context.Context, redisClient redis.Service, rates exchange.RateProvider, config Config, params pkg.QueryParams, results ...vendorResult) (redis.VendorOffers, error) {
+	flightOffers := []pkg.FlightOffer{}
+	fares := []pkg.CalendarDay{}
+	reports := []pkg.VendorReport{}
+	for _, result := range results {
+		flightOffers = append(flightOffers, result.offers...)
+		fares = append(fares, result.fares...)
+		if result.timedOut {
+			reports = append(reports, mapping.NewTimedOutVendorReport(result.source, result.latency, result.err))
+			continue
+		}
+
+		if errors.Is(result.err, ErrCircuitOpen) || errors.Is(result.err, ErrQuotaExhausted) {
+			reports = append(reports, mapping.NewSkippedVendorReport(result.source, len(result.offers), result.latency, result.err))
+			continue
+		}
+
+		reports = append(reports, mapping.NewVendorReport(result.source, len(result.offers), result.latency, result.err))
+	}
+
+	vendors := mapping.MergeVendorReports(reports...)
+	if err := config.VendorPolicy.Check(vendors...); err != nil {
+		return redis.VendorOffers{}, err
+	}
+
+	recordCalendarFares(ctx, redisClient, params, fares...)
+
+	// vendors might not price in the currency requested, so every offer is converted before comparing them
+	flightOffers, err := mapping.ConvertFlightOffers(rates, params.CurrencyCode(), flightOffers...)
+	if err != nil {
+		return redis.VendorOffers{}, err
+	}
+
+	// the same flights are usually sold by several vendors, so they are merged into a single offer
+	return redis.VendorOffers{Offers: mapping.MergeFlightOffers(flightOffers...), Vendors: vendors}, nil
+}
+
+// failedVendors reports whether any vendor failed on a search
+func failedVendors(reports ...pkg.VendorReport) bool {
+	for _, report := range reports {
+		if report.Error != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newBestFlightsResponse filters and ranks the offers found by vendors, as requested by the search
+func newBestFlightsResponse(config Config, params pkg.QueryParams, flightOffers []pkg.FlightOffer, vendors []pkg.VendorReport) pkg.GetBestFlightOffersResponse {
+	// stats are taken before filtering, so clients know what else is available
+	stats := mapping.NewFilterStats(flightOffers...)
+	flightOffers = mapping.FilterFlightOffers(params, flightOffers...)
+	response := mapping.NewBestFlightsOffersResponse(flightOffers...)
+	response.Best = mapping.RankFlightOffers(params.RankingWeights(config.RankingWeights), params.DepartureTimePreference, flightOffers...)
+	response.FilterStats = &stats
+	response.Vendors = vendors
+	response.Warnings = mapping.NewVendorWarnings(vendors...)
+	return response
+}
+
+// calendarFares lists the calendar fare found by a vendor request, if any
+func calendarFares(fare pkg.CalendarDay, ok bool) []pkg.CalendarDay {
+	if !ok {
+		return nil
+	}
+
+	return []pkg.CalendarDay{fare}
+}
+
+// searchProvider searches the flight offers of a provider, along with the lowest fare it quotes for the calendar
+func searchProvider(ctx context.Context, provider providers.FlightProvider, search pkg.QueryParams, retrievedAt time.Time) ([]pkg.FlightOffer, []pkg.CalendarDay, error) {
+	var (
+		offers []pkg.FlightOffer
+		fare   pkg.CalendarDay
+		quoted bool
+		err    error
+	)
+
+	if fareProvider, ok := provider.(providers.FareProvider); ok {
+		var price pkg.Amount
+		offers, price, quoted, err = fareProvider.RetrieveFlightOffersWithFare(ctx, search)
+		if quoted {
+			fare = mapping.NewFareCalendarDay(provider.Source(), search.Date, retrievedAt, price)
+		}
+	} else {
+		offers, err = provider.RetrieveFlightOffers(ctx, search)
+	}
+
+	offers = mapping.NewVendorPricedFlightOffers(provider.Source(), offers...)
+	log.Printf("found %v flights with %s from %s to %s", len(offers), provider.Source(), search.Origin, search.Destination)
+	if !quoted {
+		fare, quoted = mapping.NewLowestFareCalendarDay(provider.Source(), search.Date, retrievedAt, offers...)
 	}
+
+	return offers, calendarFares(fare, quoted), err
+}
+
+// providerSearches splits a search into the searches a provider is able to handle, city codes and airport lists
+// are expanded into every airport pair unless the provider searches several airports at once
+func providerSearches(capabilities providers.Capabilities, params pkg.QueryParams) []pkg.QueryParams {
+	if capabilities.MultiAirport && !params.IsMultiCity() {
+		search := params
+		search.Origin = strings.Join(airports.Expand(params.Origin), ",")
+		search.Destination = strings.Join(airports.Expand(params.Destination), ",")
+		return []pkg.QueryParams{search}
+	}
+
+	return expandAirports(params)
+}
+
+// expandAirports splits a search into one search per origin and destination airport pair
+// Multi-city legs are kept as they are, as amadeus is able to search city codes on its own
+func expandAirports(params pkg.QueryParams) []pkg.QueryParams {
+	if params.IsMultiCity() {
+		return []pkg.QueryParams{params}
+	}
+
+	searches := []pkg.QueryParams{}
+	for _, origin := range airports.Expand(params.Origin) {
+		for _, destination := range airports.Expand(params.Destination) {
+			if origin == destination {
+				continue
+			}
+
+			search := params
+			search.Origin = origin
+			search.Destination = destination
+			searches = append(searches, search)
+		}
+	}
+
+	return searches
 }
@@ -1,28 +1,226 @@
 package pkg
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
+// Cabin classes available for searching
+const (
+	CabinEconomy        = "ECONOMY"
+	CabinPremiumEconomy = "PREMIUM_ECONOMY"
+	CabinBusiness       = "BUSINESS"
+	CabinFirst          = "FIRST"
+)
+
+// DefaultCurrency is the currency prices are quoted in when none is requested
+const DefaultCurrency = "USD"
+
+// Departure time preferences available for ranking
+const (
+	DepartureTimeMorning   = "MORNING"   // 05:00 to 11:59
+	DepartureTimeAfternoon = "AFTERNOON" // 12:00 to 17:59
+	DepartureTimeEvening   = "EVENING"   // 18:00 to 23:59
+	DepartureTimeNight     = "NIGHT"     // 00:00 to 04:59
+)
+
+// Sort orders available for searching
+const (
+	SortCheapest          = "CHEAPEST"
+	SortFastest           = "FASTEST"
+	SortEarliestDeparture = "EARLIEST_DEPARTURE"
+	SortLatestDeparture   = "LATEST_DEPARTURE"
+	SortEarliestArrival   = "EARLIEST_ARRIVAL"
+	SortFewestStops       = "FEWEST_STOPS"
+	SortLowestEmissions   = "LOWEST_EMISSIONS"
+	SortPricePerHour      = "PRICE_PER_HOUR"
+)
+
+// Dimensions available for the pareto-optimal offers
+const (
+	ParetoPriceDuration      = "PRICE_DURATION"
+	ParetoPriceDurationStops = "PRICE_DURATION_STOPS"
+)
+
+// Maximum number of stops available for searching
+const (
+	MaxStopsDirect = 0
+	MaxStopsOne    = 1
+	MaxStopsAny    = 2 // two or more stops
+)
+
 // QueryParams represents our API available query parameters for decoding
 type QueryParams struct {
+	Origin        string `json:"origin"`
+	Adults        int    `json:"adults"`
+	Children      int    `json:"children"`
+	InfantsOnLap  int    `json:"infantsOnLap"`
+	InfantsInSeat int    `json:"infantsInSeat"`
+	Seniors       int    `json:"seniors"`
+	Cabin         string `json:"cabin"`
+	MaxStops      int    `json:"maxStops"`
+	// connection filters, in minutes
+	MinConnectionTime          int       `json:"minConnectionTime"`
+	MaxConnectionTime          int       `json:"maxConnectionTime"`
+	ExcludedConnectionAirports []string  `json:"excludedConnectionAirports"`
+	Destination                string    `json:"destination"`
+	Date                       time.Time `json:"date"`
+	ReturnDate                 time.Time `json:"returnDate"`
+	FlexDays                   int       `json:"flexDays"` // searches departure dates within ±N days
+	Currency                   string    `json:"currency"`
+	// ranking preferences, weights left empty fall back to the deployment defaults
+	DepartureTimePreference string   `json:"departureTime"`
+	PriceWeight             *float64 `json:"priceWeight"`
+	DurationWeight          *float64 `json:"durationWeight"`
+	StopsWeight             *float64 `json:"stopsWeight"`
+	DepartureTimeWeight     *float64 `json:"departureTimeWeight"`
+	EmissionsWeight         *float64 `json:"emissionsWeight"`
+	// result filters, time windows are local times of the outbound bound formatted as HH:MM
+	IncludedAirlines  []string `json:"includedAirlines"`
+	ExcludedAirlines  []string `json:"excludedAirlines"`
+	DepartureTimeFrom string   `json:"departureTimeFrom"`
+	DepartureTimeTo   string   `json:"departureTimeTo"`
+	ArrivalTimeFrom   string   `json:"arrivalTimeFrom"`
+	ArrivalTimeTo     string   `json:"arrivalTimeTo"`
+	MaxPrice          float64  `json:"maxPrice"`    // in the requested currency
+	MaxDuration       int      `json:"maxDuration"` // in minutes
+	// sorting, pareto-optimal offers and pagination are left out of the encoded search criteria, as they are applied on top of the same results
+	Sort   string `json:"sort"`
+	Pareto string `json:"pareto"` // dimensions of the pareto-optimal offers, none are returned when empty
+	Limit  int    `json:"limit"`
+	Cursor string `json:"cursor"`
+	Legs   []Leg  `json:"legs"`
+	Token  string `json:"token"`
+}
+
+// SearchKey encodes only the search criteria sent to vendors, leaving out the filters and ranking applied once offers
+// are mapped, so searches only differing on those share the same vendor offers
+func (q QueryParams) SearchKey() string {
+	legs := make([]string, 0, len(q.Legs))
+	for _, leg := range q.Legs {
+		legs = append(legs, fmt.Sprintf("%s-%s-%s", leg.Origin, leg.Destination, leg.Date.Format("2006-01-02")))
+	}
+
+	return fmt.Sprintf("origin=%s&adults=%d&children=%d&infantsOnLap=%d&infantsInSeat=%d&seniors=%d&cabin=%s&maxStops=%d&destination=%s&date=%s&returnDate=%s&flexDays=%d&currency=%s&legs=%s",
+		q.Origin, q.Adults, q.Children, q.InfantsOnLap, q.InfantsInSeat, q.Seniors, q.Cabin, q.MaxStops,
+		q.Destination, q.Date, q.ReturnDate, q.FlexDays, q.Currency, strings.Join(legs, ","))
+}
+
+// CurrencyCode returns the currency prices should be quoted in
+func (q QueryParams) CurrencyCode() string {
+	if q.Currency == "" {
+		return DefaultCurrency
+	}
+
+	return q.Currency
+}
+
+// RankingWeights returns the weights to rank flight offers with, overriding the defaults with the ones requested
+func (q QueryParams) RankingWeights(defaults RankingWeights) RankingWeights {
+	weights := defaults
+	overrides := []struct {
+		value  *float64
+		target *float64
+	}{
+		{q.PriceWeight, &weights.Price},
+		{q.DurationWeight, &weights.Duration},
+		{q.StopsWeight, &weights.Stops},
+		{q.DepartureTimeWeight, &weights.DepartureTime},
+		{q.EmissionsWeight, &weights.Emissions},
+	}
+
+	for _, override := range overrides {
+		if override.value != nil {
+			*override.target = *override.value
+		}
+	}
+
+	return weights
+}
+
+// Travelers returns the amount of passengers included in the search criteria
+func (q QueryParams) Travelers() int {
+	return q.Adults + q.Children + q.InfantsOnLap + q.InfantsInSeat + q.Seniors
+}
+
+// IsRoundTrip reports whether the search criteria includes a return date
+func (q QueryParams) IsRoundTrip() bool {
+	return !q.ReturnDate.IsZero()
+}
+
+// IsMultiCity reports whether the search criteria is made of several legs instead of a single origin and destination
+func (q QueryParams) IsMultiCity() bool {
+	return len(q.Legs) > 0
+}
+
+// Leg represents a single origin and destination pair of a multi-city search
+type Leg struct {
 	Origin      string    `json:"origin"`
-	Adults      string    `json:"adults"`
 	Destination string    `json:"destination"`
 	Date        time.Time `json:"date"`
-	Token       string    `json:"token"`
 }
 
-// Encode generates an encoded query string
-func (q QueryParams) Encode() string {
-	return fmt.Sprintf("origin=%s&adults=%s&destination=%s&date=%s", q.Origin, q.Adults, q.Destination, q.Date)
+// UnmarshalJSON decodes a leg, simplifying date parsing to ignore time as we do on query params
+func (l *Leg) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Origin      string `json:"origin"`
+		Destination string `json:"destination"`
+		Date        string `json:"date"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	l.Origin = raw.Origin
+	l.Destination = raw.Destination
+	if raw.Date == "" {
+		return nil
+	}
+
+	date, err := time.Parse("2006-01-02", raw.Date)
+	if err != nil {
+		return err
+	}
+
+	l.Date = date
+	return nil
 }
 
 // Location represents flight location and time
 type Location struct {
 	Timestamp time.Time `json:"timestamp"`
 	IataCode  string    `json:"iataCode"`
+	Terminal  string    `json:"terminal,omitempty"`
+}
+
+// Carrier represents an airline taking part on a flight segment
+type Carrier struct {
+	Code string `json:"code,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// Connection represents a layover between two consecutive segments of an itinerary
+type Connection struct {
+	Airport           string  `json:"airport"`
+	DepartureAirport  string  `json:"departureAirport,omitempty"` // only present on a change of airport
+	DurationInMinutes float64 `json:"durationInMinutes"`
+	Overnight         bool    `json:"overnight"`
+	ChangeOfAirport   bool    `json:"changeOfAirport"`
+	SelfTransfer      bool    `json:"selfTransfer"` // travelers must collect and re-check their bags on their own
+}
+
+// Segment represents a single flight within an itinerary, e.g each of the flights of a connection
+type Segment struct {
+	MarketingCarrier  Carrier  `json:"marketingCarrier"`
+	OperatingCarrier  Carrier  `json:"operatingCarrier"`
+	FlightNumber      string   `json:"flightNumber"`
+	Aircraft          string   `json:"aircraft,omitempty"`
+	Departure         Location `json:"departure"`
+	Arrival           Location `json:"arrival"`
+	DurationInMinutes float64  `json:"durationInMinutes"`
 }
 
 // Amount represents flight pricing
@@ -31,21 +229,289 @@ type Amount struct {
 	Currency string  `json:"currency"`
 }
 
+// Itinerary represents a single bound of a flight offer, e.g the outbound or the inbound flight
+type Itinerary struct {
+	Airline           string       `json:"airline"`
+	FlightNumber      string       `json:"flightNumber"`
+	Arrival           Location     `json:"arrival"`
+	Departure         Location     `json:"departure"`
+	DurationInMinutes float64      `json:"durationInMinutes"`
+	Layovers          int          `json:"layovers"`
+	Cabin             string       `json:"cabin,omitempty"` // only present when the vendor reports the booked cabin
+	Segments          []Segment    `json:"segments"`
+	Connections       []Connection `json:"connections,omitempty"`
+	EmissionsInGrams  float64      `json:"emissionsInGrams,omitempty"` // only present when the vendor reports carbon emissions
+}
+
+// TravelerPricing represents the price paid by a single traveler within a flight offer
+type TravelerPricing struct {
+	TravelerID   string `json:"travelerId"`
+	TravelerType string `json:"travelerType"`
+	Price        Amount `json:"price"`
+}
+
 // FlightOffer represents flight offer breakdown
+// The embedded itinerary is the outbound flight, while the price covers every bound of the offer
 type FlightOffer struct {
-	Airline           string   `json:"airline"`
-	FlightNumber      string   `json:"flightNumber"`
-	Arrival           Location `json:"arrival"`
-	Departure         Location `json:"departure"`
-	DurationInMinutes float64  `json:"durationInMinutes"`
-	Layovers          int      `json:"layovers"`
-	Price             Amount   `json:"price"`
+	Itinerary
+	Price Amount `json:"price"`
+	// Source is the vendor quoting the price of the offer
+	Source string `json:"source,omitempty"`
+	// Itineraries lists every bound in travel order, only present for round trips and multi-city offers
+	Itineraries []Itinerary `json:"itineraries,omitempty"`
+	// TravelerPricings breaks down the price per traveler, only present when the vendor provides it
+	TravelerPricings []TravelerPricing `json:"travelerPricings,omitempty"`
+	// Prices lists every vendor quoting the same flights, cheapest first
+	Prices []VendorPrice `json:"prices,omitempty"`
+	// Ranking explains the position of the offer, only present on the best flight offers
+	Ranking *Ranking `json:"ranking,omitempty"`
+}
+
+// Bounds returns every bound of the offer in travel order
+func (f FlightOffer) Bounds() []Itinerary {
+	if len(f.Itineraries) == 0 {
+		return []Itinerary{f.Itinerary}
+	}
+
+	return f.Itineraries
+}
+
+// TotalEmissionsInGrams returns the carbon emissions of all bounds in the offer, zero when not reported
+func (f FlightOffer) TotalEmissionsInGrams() float64 {
+	total := 0.0
+	for _, bound := range f.Bounds() {
+		total += bound.EmissionsInGrams
+	}
+
+	return total
+}
+
+// TotalLayovers returns the number of stops of all bounds in the offer
+func (f FlightOffer) TotalLayovers() int {
+	total := 0
+	for _, bound := range f.Bounds() {
+		total += bound.Layovers
+	}
+
+	return total
+}
+
+// TotalDurationInMinutes returns the flying time of all bounds in the offer
+func (f FlightOffer) TotalDurationInMinutes() float64 {
+	if len(f.Itineraries) == 0 {
+		return f.DurationInMinutes
+	}
+
+	total := 0.0
+	for _, itinerary := range f.Itineraries {
+		total += itinerary.DurationInMinutes
+	}
+
+	return total
+}
+
+// RankingWeights represents how much each criteria weighs when ranking flight offers
+type RankingWeights struct {
+	Price         float64 `json:"price"`
+	Duration      float64 `json:"duration"`
+	Stops         float64 `json:"stops"`
+	DepartureTime float64 `json:"departureTime"`
+	Emissions     float64 `json:"emissions"`
+}
+
+// RankingFactor represents how a flight offer scored on a single criteria, from 0 (worst) to 1 (best) among the offers ranked
+type RankingFactor struct {
+	Criteria string  `json:"criteria"`
+	Score    float64 `json:"score"`
+	Weight   float64 `json:"weight"`
+}
+
+// Ranking represents the weighted score of a flight offer, from 0 to 100, along with the factors behind it
+type Ranking struct {
+	Score     float64         `json:"score"`
+	Breakdown []RankingFactor `json:"breakdown"`
+}
+
+// VendorPrice represents the price a vendor quoted for a flight offer
+type VendorPrice struct {
+	Source string `json:"source"`
+	Price  Amount `json:"price"`
+}
+
+// AirlineStats represents how many flight offers are available for an airline, and the cheapest of them
+type AirlineStats struct {
+	Carrier
+	Offers      int    `json:"offers"`
+	LowestPrice Amount `json:"lowestPrice"`
+}
+
+// PriceRange represents the lowest and highest price among flight offers
+type PriceRange struct {
+	Min Amount `json:"min"`
+	Max Amount `json:"max"`
+}
+
+// DurationRange represents the shortest and longest duration among flight offers, in minutes
+type DurationRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// FilterStats represents what is available to filter on among all flight offers found, before applying any filter
+type FilterStats struct {
+	Airlines []AirlineStats `json:"airlines"`
+	Price    PriceRange     `json:"price"`
+	Duration DurationRange  `json:"durationInMinutes"`
+}
+
+// DailyFlightOffers represents the best flight offers for a single departure date within a flexible date search
+type DailyFlightOffers struct {
+	Date     time.Time    `json:"date"`
+	Cheapest *FlightOffer `json:"cheapest"`
+	Fastest  *FlightOffer `json:"fastest"`
 }
 
 // GetBestFlightOffersResponse is the response for best flights API
 type GetBestFlightOffersResponse struct {
 	Cheapest []FlightOffer `json:"cheapest"`
 	Fastest  []FlightOffer `json:"fastest"`
+	Best     []FlightOffer `json:"best,omitempty"` // ranked by a weighted score over several criteria
+	// FilterStats summarizes every offer found, so clients are able to build filters without downloading all of them
+	FilterStats *FilterStats `json:"filterStats,omitempty"`
+	// Days breaks down the best offers per departure date, only present on flexible date searches
+	Days []DailyFlightOffers `json:"days,omitempty"`
+	// Sorted lists every offer in the requested sort order, only present when a sort order is requested
+	Sorted []FlightOffer `json:"sorted,omitempty"`
+	// Pareto lists the offers no other offer beats on every dimension requested, cheapest first
+	Pareto []FlightOffer `json:"pareto,omitempty"`
+	// Pagination is only present when results are limited
+	Pagination *Pagination `json:"pagination,omitempty"`
+	// Vendors reports how each vendor performed on the search
+	Vendors []VendorReport `json:"vendors,omitempty"`
+	// Warnings lists the vendors failing on the search, only present on partial results
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// VendorReport summarizes the search of a single vendor, across all the airports searched
+type VendorReport struct {
+	Source      string `json:"source"`
+	Offers      int    `json:"offers"`      // offers found before merging and filtering them
+	LatencyInMs int64  `json:"latencyInMs"` // slowest of the vendor requests, zero when served from cache
+	Cache       string `json:"cache"`
+	TimedOut    bool   `json:"timedOut,omitempty"` // the vendor did not answer within the search budget
+	Error       string `json:"error,omitempty"`
+	Skipped     string `json:"skipped,omitempty"` // why the vendor was not searched, e.g an open circuit or an exhausted quota
+}
+
+// Pagination represents a page of flight offers, every list holding all offers is paginated the same way,
+// pareto-optimal offers are never paginated
+type Pagination struct {
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Total      int    `json:"total"`                // offers found across all pages
+	NextCursor string `json:"nextCursor,omitempty"` // only present when there are more pages
+}
+
+// Vendors a flight offer or fare can come from
+const (
+	SourceAmadeus       = "amadeus"
+	SourceGoogleflights = "googleflights"
+	SourceFlightsky     = "flightsky"
+)
+
+// Cache status of a vendor search
+const (
+	CacheHit  = "HIT"
+	CacheMiss = "MISS"
+)
+
+// States of a vendor circuit breaker
+const (
+	BreakerClosed   = "CLOSED"    // the vendor is searched
+	BreakerOpen     = "OPEN"      // the vendor is skipped until its cool-down is over
+	BreakerHalfOpen = "HALF_OPEN" // a single search probes whether the vendor recovered
+)
+
+// Windows of a vendor quota
+const (
+	QuotaWindowSecond = "SECOND" // rate limit
+	QuotaWindowDay    = "DAY"
+	QuotaWindowMonth  = "MONTH"
+)
+
+// Freshness of a calendar fare
+const (
+	FreshnessLive   = "LIVE"   // retrieved from the vendor while serving the request
+	FreshnessCached = "CACHED" // recorded from a previous search
+)
+
+// CalendarQueryParams represents our price calendar API available query parameters for decoding
+type CalendarQueryParams struct {
+	Origin      string `json:"origin"`
+	Destination string `json:"destination"`
+	Month       string `json:"month"` // e.g 2025-05
+	Currency    string `json:"currency"`
+	Token       string `json:"token"`
+}
+
+// CurrencyCode returns the currency fares should be quoted in
+func (q CalendarQueryParams) CurrencyCode() string {
+	if q.Currency == "" {
+		return DefaultCurrency
+	}
+
+	return q.Currency
+}
+
+// FirstDay returns the first day of the month requested
+func (q CalendarQueryParams) FirstDay() (time.Time, error) {
+	return time.Parse("2006-01", q.Month)
+}
+
+// CalendarDay represents the lowest known fare for a single departure date
+type CalendarDay struct {
+	Date        time.Time  `json:"date"`
+	Price       *Amount    `json:"price"` // nil when no fare is known for the day
+	Source      string     `json:"source,omitempty"`
+	Freshness   string     `json:"freshness,omitempty"`
+	RetrievedAt *time.Time `json:"retrievedAt,omitempty"`
+}
+
+// GetCalendarResponse is the response for price calendar API
+type GetCalendarResponse struct {
+	Origin      string        `json:"origin"`
+	Destination string        `json:"destination"`
+	Month       string        `json:"month"`
+	Days        []CalendarDay `json:"days"`
+}
+
+// GetVendorDiagnosticsResponse is the response for vendor diagnostics API
+type GetVendorDiagnosticsResponse struct {
+	Vendors []VendorDiagnostics `json:"vendors"`
+}
+
+// VendorDiagnostics represents the health of a single vendor
+type VendorDiagnostics struct {
+	Source  string        `json:"source"`
+	Breaker BreakerStatus `json:"breaker"`
+	Quotas  []QuotaStatus `json:"quotas,omitempty"` // only present for vendors with limited calls
+}
+
+// BreakerStatus represents the state of the circuit breaker of a vendor
+type BreakerStatus struct {
+	State    string     `json:"state"`
+	Failures int        `json:"failures"`           // consecutive failures since the last success
+	OpenedAt *time.Time `json:"openedAt,omitempty"` // only present while not closed
+	RetryAt  *time.Time `json:"retryAt,omitempty"`  // only present while open
+}
+
+// QuotaStatus represents the calls made to a vendor within the current quota window, shared by every instance
+type QuotaStatus struct {
+	Window    string    `json:"window"`
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining"`
+	ResetAt   time.Time `json:"resetAt"`
 }
 
 // CrendetialsRequest represents app credentials